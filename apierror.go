@@ -0,0 +1,140 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIError is an error with a message which is safe to show to clients, and a cause which is
+// only logged. The default OnError renders it as a Problem with the public message as the
+// detail, and the error code and field errors as extension members.
+type APIError struct {
+	// Status is the HTTP status code
+	Status int
+	// ErrorCode is a stable, machine readable code for the error, like "user_not_found"
+	ErrorCode string
+	// Message is the public message sent to the client
+	Message string
+	// Cause is the underlying error, which is logged but never sent to the client
+	Cause error
+	// Fields are the validation errors for individual fields
+	Fields []FieldError
+	// RetryAfter, if set, is sent as the Retry-After header
+	RetryAfter time.Duration
+	// HelpURL links to documentation for the error, and is used as the problem type
+	HelpURL string
+}
+
+// WithMessage returns an *APIError with the status code and a formatted public message
+func (s StatusCode) WithMessage(format string, args ...interface{}) *APIError {
+	return &APIError{Status: s.Code(), Message: fmt.Sprintf(format, args...)}
+}
+
+// WithCode sets the machine readable error code
+func (e *APIError) WithCode(code string) *APIError {
+	e.ErrorCode = code
+	return e
+}
+
+// WithCause sets the private cause of the error
+func (e *APIError) WithCause(err error) *APIError {
+	e.Cause = err
+	return e
+}
+
+// WithField adds a validation error for a field
+func (e *APIError) WithField(name string, err error) *APIError {
+	e.Fields = append(e.Fields, FieldError{Name: name, Err: err})
+	return e
+}
+
+// WithRetryAfter sets the Retry-After duration
+func (e *APIError) WithRetryAfter(d time.Duration) *APIError {
+	e.RetryAfter = d
+	return e
+}
+
+// WithHelpURL sets the link to the documentation for the error
+func (e *APIError) WithHelpURL(url string) *APIError {
+	e.HelpURL = url
+	return e
+}
+
+// Error returns the public message followed by the cause, for logging
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.Code())
+	}
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %v", msg, e.Cause)
+	}
+	return msg
+}
+
+// Unwrap returns the cause
+func (e *APIError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is the StatusCode of the error, so errors.Is(err, StatusNotFound) works
+func (e *APIError) Is(target error) bool {
+	s, ok := target.(StatusCode)
+	return ok && s.Code() == e.Code()
+}
+
+// Code returns the status code, or 500 if it's not set
+func (e *APIError) Code() int {
+	if e.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return e.Status
+}
+
+// ContentType returns the application/problem+json content type
+func (e *APIError) ContentType() string {
+	return MIMEApplicationProblemJSON.String()
+}
+
+// Problem returns the public parts of the error as a Problem
+func (e *APIError) Problem() *Problem {
+	p := NewProblem(e.Code(), e.Message)
+	p.Type = e.HelpURL
+	if e.ErrorCode != "" {
+		p.With("code", e.ErrorCode)
+	}
+	if len(e.Fields) > 0 {
+		fields := make([]fieldProblem, len(e.Fields))
+		for i, f := range e.Fields {
+			fields[i] = fieldProblem{Source: f.Source, Name: f.Name}
+			if f.Err != nil {
+				fields[i].Message = f.Err.Error()
+			}
+		}
+		p.With("fields", fields)
+	}
+	if e.RetryAfter > 0 {
+		p.With("retry_after", e.retryAfter())
+	}
+	return p
+}
+
+// retryAfter returns the Retry-After duration in whole seconds, rounded up
+func (e *APIError) retryAfter() int {
+	return int((e.RetryAfter + time.Second - 1) / time.Second)
+}
+
+// setHeaders sets the Retry-After header if the error has a RetryAfter duration
+func (e *APIError) setHeaders(h http.Header) {
+	if e.RetryAfter > 0 {
+		h.Set("Retry-After", strconv.Itoa(e.retryAfter()))
+	}
+}
+
+type fieldProblem struct {
+	Source  string `json:"source,omitempty" xml:"source,attr,omitempty"`
+	Name    string `json:"name" xml:"name,attr"`
+	Message string `json:"message" xml:",chardata"`
+}
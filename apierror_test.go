@@ -0,0 +1,57 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIError(t *testing.T) {
+	cause := errors.New("sql: no rows in result set")
+	err := StatusNotFound.WithMessage("user %d not found", 42).WithCode("user_not_found").WithCause(cause)
+
+	assert.Equal(t, http.StatusNotFound, err.Code())
+	assert.Equal(t, "user 42 not found: sql: no rows in result set", err.Error())
+	assert.True(t, errors.Is(err, cause))
+	assert.True(t, errors.Is(err, StatusNotFound))
+	assert.False(t, errors.Is(err, StatusBadRequest))
+	assert.Equal(t, MIMEApplicationProblemJSON.String(), err.ContentType())
+
+	w := serveError("", fmt.Errorf("get user: %w", err))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.NotContains(t, w.Body.String(), "sql")
+	assert.JSONEq(t, `{"status":404,"title":"Not Found","detail":"user 42 not found","code":"user_not_found"}`, w.Body.String())
+}
+
+func TestAPIErrorFields(t *testing.T) {
+	err := StatusUnprocessableEntity.WithMessage("validation failed").
+		WithField("email", errors.New("is required")).
+		WithRetryAfter(1500 * time.Millisecond).
+		WithHelpURL("https://example.com/errors/validation")
+
+	w := serveError("", err)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+
+	var got struct {
+		Type       string `json:"type"`
+		RetryAfter int    `json:"retry_after"`
+		Fields     []struct {
+			Name    string `json:"name"`
+			Message string `json:"message"`
+		} `json:"fields"`
+	}
+	if assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got)) {
+		assert.Equal(t, "https://example.com/errors/validation", got.Type)
+		assert.Equal(t, 2, got.RetryAfter)
+		if assert.Len(t, got.Fields, 1) {
+			assert.Equal(t, "email", got.Fields[0].Name)
+			assert.Equal(t, "is required", got.Fields[0].Message)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+var (
+	_ http.Handler = (*App)(nil)
+)
+
+var contextKeyApp = &contextKey{"app"}
+
+// DefaultApp is the App used by the package-level funcs like Handler() and Init()
+var DefaultApp = NewApp()
+
+// App owns the hooks and settings used to handle requests, so that APIs with different
+// error policies can run side by side, and tests can use their own hooks without changing
+// the package variables. Hooks which are nil fall back to the package variable of the same
+// name, so a zero App behaves the same as the package-level funcs.
+type App struct {
+	OnError        func(ctx context.Context, code int, err error)
+	OnPanic        AppHandler
+	GetErrorCode   func(ctx context.Context, err error) int
+	Namespace      func(ctx context.Context) (string, error)
+	OnUnauthorized http.HandlerFunc
+	UserFunc       func(ctx context.Context, user interface{}) error
+
+	// ErrorCodes is the registry used by the default GetErrorCode
+	ErrorCodes *ErrorRegistry
+
+	// Criticalf logs recovered panics, and Errorf logs errors in the default OnError
+	Criticalf LogFunc
+	Errorf    LogFunc
+
+	// Environment, if set, is stored under ContextKeyEnvironment for every request
+	Environment string
+
+	// StrictDecoding turns strict mode on or off for JSON decoded with Decode(), overriding
+	// the package StrictDecoding. If it's nil the package setting is used.
+	StrictDecoding *bool
+	// MaxDecodeDepth overrides the package MaxDecodeDepth if it's not nil, where zero means
+	// there's no limit
+	MaxDecodeDepth *int
+	// MaxMultipartMemory overrides the package MaxMultipartMemory if it's not zero
+	MaxMultipartMemory int64
+	// DefaultMIME overrides the package DefaultMIME if it's set
+	DefaultMIME MIME
+
+	routerOnce sync.Once
+	router     *Router
+}
+
+// NewApp creates a new App
+func NewApp() *App {
+	return &App{}
+}
+
+// AppFromContext returns the App handling the request, or DefaultApp if there isn't one
+func AppFromContext(ctx context.Context) *App {
+	if a, ok := Value[*App](ctx, contextKeyApp); ok && a != nil {
+		return a
+	}
+	return DefaultApp
+}
+
+// Handler works like the package-level Handler(), using the hooks of the app
+func (a *App) Handler(fn ...AppHandler) http.Handler {
+	return a.handler(nil, fn)
+}
+
+// Init works like the package-level Init(), using the hooks of the app
+func (a *App) Init(w http.ResponseWriter, r *http.Request) context.Context {
+	ctx, _ := a.initContext(w, r)
+	return ctx
+}
+
+// ToMiddleware works like the package-level ToMiddleware(), using the hooks of the app
+func (a *App) ToMiddleware(fn ...AppHandler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return a.Handler(append(append([]AppHandler{}, fn...), FromHandler(next))...)
+	}
+}
+
+// Router returns the router of the app. Routes added to it use the hooks of the app.
+func (a *App) Router() *Router {
+	a.routerOnce.Do(func() {
+		a.router = NewRouter()
+		a.router.app = a
+	})
+	return a.router
+}
+
+// ServeHTTP implements the http.Handler interface with the router of the app
+func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.Router().ServeHTTP(w, r)
+}
+
+// User calls the UserFunc of the app to get the user associated with the current request
+func (a *App) User(ctx context.Context, user interface{}) error {
+	fn := a.UserFunc
+	if fn == nil {
+		fn = UserFunc
+	}
+	if fn == nil {
+		return ErrNoUserFunc
+	}
+	return fn(ctx, user)
+}
+
+// Unauthorized writes a 401 response with the OnUnauthorized hook of the app
+func (a *App) Unauthorized(w http.ResponseWriter, r *http.Request) {
+	if a.OnUnauthorized != nil {
+		a.OnUnauthorized(w, r)
+		return
+	}
+	OnUnauthorized(w, r)
+}
+
+// handleError passes the error to the OnError hook with the code from GetErrorCode
+func (a *App) handleError(ctx context.Context, err error) {
+	a.onError()(ctx, a.getErrorCode()(ctx, err), err)
+}
+
+func (a *App) onError() func(ctx context.Context, code int, err error) {
+	if a.OnError != nil {
+		return a.OnError
+	}
+	return OnError
+}
+
+func (a *App) getErrorCode() func(ctx context.Context, err error) int {
+	if a.GetErrorCode != nil {
+		return a.GetErrorCode
+	}
+	return GetErrorCode
+}
+
+func (a *App) onPanic() AppHandler {
+	if a.OnPanic != nil {
+		return a.OnPanic
+	}
+	return OnPanic
+}
+
+func (a *App) namespace() func(ctx context.Context) (string, error) {
+	if a.Namespace != nil {
+		return a.Namespace
+	}
+	return Namespace
+}
+
+func (a *App) errorCodes() *ErrorRegistry {
+	if a.ErrorCodes != nil {
+		return a.ErrorCodes
+	}
+	return ErrorCodes
+}
+
+func (a *App) strictDecoding() bool {
+	if a.StrictDecoding != nil {
+		return *a.StrictDecoding
+	}
+	return StrictDecoding
+}
+
+func (a *App) maxDecodeDepth() int {
+	if a.MaxDecodeDepth != nil {
+		return *a.MaxDecodeDepth
+	}
+	return MaxDecodeDepth
+}
+
+func (a *App) maxMultipartMemory() int64 {
+	if a.MaxMultipartMemory != 0 {
+		return a.MaxMultipartMemory
+	}
+	return MaxMultipartMemory
+}
+
+func (a *App) defaultMIME() MIME {
+	if a.DefaultMIME != "" {
+		return a.DefaultMIME
+	}
+	return DefaultMIME
+}
+
+func (a *App) criticalf() LogFunc {
+	if a.Criticalf != nil {
+		return a.Criticalf
+	}
+	return Criticalf
+}
+
+func (a *App) errorf() LogFunc {
+	if a.Errorf != nil {
+		return a.Errorf
+	}
+	return Errorf
+}
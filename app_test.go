@@ -0,0 +1,182 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppHooks(t *testing.T) {
+	errTeapot := errors.New("teapot")
+	for _, code := range []int{http.StatusConflict, http.StatusTeapot} {
+		code := code
+		t.Run(http.StatusText(code), func(t *testing.T) {
+			t.Parallel()
+
+			var gotCode int
+			var logged bool
+			reg := NewErrorRegistry()
+			reg.Register(errTeapot, code)
+			app := &App{
+				ErrorCodes: reg,
+				Errorf: func(ctx context.Context, format string, args ...interface{}) {
+					logged = true
+				},
+				OnError: func(ctx context.Context, code int, err error) {
+					gotCode = code
+					OnError(ctx, code, err)
+				},
+			}
+
+			w := serve(app.Handler(func(ctx context.Context) error {
+				assert.Equal(t, app, AppFromContext(ctx))
+				return errTeapot
+			}), "GET", "/")
+			assert.Equal(t, code, w.Code)
+			assert.Equal(t, code, gotCode)
+			assert.True(t, logged)
+		})
+	}
+}
+
+func TestAppOnPanic(t *testing.T) {
+	app := &App{
+		Criticalf: func(ctx context.Context, format string, args ...interface{}) {},
+		OnPanic: func(ctx context.Context) error {
+			return Text(ctx, http.StatusServiceUnavailable, Recovered(ctx))
+		},
+	}
+	w := serve(app.Handler(func(ctx context.Context) error {
+		panic("boom")
+	}), "GET", "/")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "boom", w.Body.String())
+}
+
+func TestAppRouter(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	app := &App{
+		Environment: "staging",
+		UserFunc: func(ctx context.Context, u interface{}) error {
+			u.(*user).Name = "foo"
+			return nil
+		},
+	}
+	api := app.Router().Group("/api")
+	api.GET("/me", func(ctx context.Context) error {
+		var u user
+		if err := User(ctx, &u); err != nil {
+			return err
+		}
+		return Text(ctx, http.StatusOK, u.Name+" "+Environment(ctx))
+	})
+
+	w := serve(app, "GET", "/api/me")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "foo staging", w.Body.String())
+}
+
+func TestDefaultApp(t *testing.T) {
+	assert.Equal(t, DefaultApp, AppFromContext(context.Background()))
+	assert.Equal(t, ErrNoUserFunc, User(context.Background(), nil))
+
+	var got *App
+	serve(Handler(func(ctx context.Context) error {
+		got = AppFromContext(ctx)
+		return nil
+	}), "GET", "/")
+	assert.Equal(t, DefaultApp, got)
+}
+
+func TestAppDeferPanicLogged(t *testing.T) {
+	var logged []string
+	app := &App{
+		Criticalf: func(ctx context.Context, format string, args ...interface{}) {
+			logged = append(logged, fmt.Sprintf(format, args...))
+		},
+	}
+	serve(app.Handler(func(ctx context.Context) error {
+		Defer(ctx, func(ctx context.Context, code int, err error) {
+			panic("hook")
+		})
+		return nil
+	}), "GET", "/")
+
+	if assert.Len(t, logged, 1) {
+		assert.Contains(t, logged[0], "panic in deferred func: hook")
+	}
+}
+
+func TestAppDecodeSettings(t *testing.T) {
+	strict, depth := true, 1
+	app := &App{StrictDecoding: &strict, MaxDecodeDepth: &depth, DefaultMIME: MIMEApplicationXML}
+
+	var decodeErr error
+	h := app.Handler(func(ctx context.Context) error {
+		var dst map[string]interface{}
+		decodeErr = Decode(ctx, &dst)
+		return Render(ctx, http.StatusOK, testDecode{Name: "foo"})
+	})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(`{"a":{"b":1}}`)))
+
+	assert.True(t, errors.Is(decodeErr, ErrMaxDepthExceeded))
+	assert.Equal(t, MIMEApplicationXMLCharsetUTF8.String(), w.Header().Get(HeaderContentType))
+
+	// The package settings are still used by other apps
+	assert.False(t, StrictDecoding)
+	assert.Equal(t, MIMEApplicationJSON, DefaultApp.defaultMIME())
+}
+
+func TestAppDecodeSettingsOff(t *testing.T) {
+	defer func() { StrictDecoding, MaxDecodeDepth = false, 0 }()
+	StrictDecoding, MaxDecodeDepth = true, 1
+
+	// The app turns off strict mode and the depth limit which are on for the package
+	strict, depth := false, 0
+	for _, app := range []*App{{StrictDecoding: &strict}, {MaxDecodeDepth: &depth}} {
+		var decodeErr error
+		h := app.Handler(func(ctx context.Context) error {
+			var dst map[string]interface{}
+			decodeErr = Decode(ctx, &dst)
+			return nil
+		})
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(`{"a":{"b":1}}`)))
+		assert.NoError(t, decodeErr)
+	}
+
+	var decodeErr error
+	NewApp().Handler(func(ctx context.Context) error {
+		var dst map[string]interface{}
+		decodeErr = Decode(ctx, &dst)
+		return nil
+	}).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", strings.NewReader(`{"a":{"b":1}}`)))
+	assert.True(t, errors.Is(decodeErr, ErrMaxDepthExceeded))
+}
+
+func TestAppToMiddleware(t *testing.T) {
+	var gotCode int
+	app := &App{
+		Errorf: func(ctx context.Context, format string, args ...interface{}) {},
+		OnError: func(ctx context.Context, code int, err error) {
+			gotCode = code
+			Text(ctx, http.StatusTeapot, "app")
+		},
+	}
+	mw := app.ToMiddleware(func(ctx context.Context) error {
+		return StatusForbidden
+	})
+	w := serve(mw(http.NotFoundHandler()), "GET", "/")
+	assert.Equal(t, http.StatusForbidden, gotCode)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "app", w.Body.String())
+}
@@ -0,0 +1,173 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// bindSources are the struct tags used by Bind(), in the order they're checked
+var bindSources = []string{"path", "query", "form", "header", "cookie"}
+
+// FieldError describes a request value which couldn't be bound to a struct field
+type FieldError struct {
+	Source string `json:"source" xml:"source,attr"`
+	Name   string `json:"name" xml:"name,attr"`
+	Value  string `json:"value,omitempty" xml:"value,omitempty"`
+	Err    error  `json:"-" xml:"-"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Source, e.Name, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// BindError is returned by Bind() with every field which couldn't be bound
+type BindError struct {
+	Fields []FieldError
+}
+
+func (e *BindError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i := range e.Fields {
+		msgs[i] = e.Fields[i].Error()
+	}
+	return "bind: " + strings.Join(msgs, "; ")
+}
+
+// Code returns a 400 status code, which implements the ResponseCode interface
+func (e *BindError) Code() int {
+	return http.StatusBadRequest
+}
+
+// Bind sets the fields of the struct pointed to by dst from the request. Fields are tagged
+// with the source of their value: `path:"id"` for mux route variables, `query:"page"`,
+// `form:"name"` for the url encoded or multipart form body, `header:"X-Foo"` and
+// `cookie:"c"`. Values are converted to the field type, which can be a string, bool,
+// number, time.Duration, a type implementing encoding.TextUnmarshaler like time.Time, or
+// a slice or pointer of those. Fields without a value in the request are left unchanged.
+// If any values can't be converted, a *BindError listing all of them is returned. A multipart
+// body is parsed to get the form values, which reads all of it, so use Upload() instead for
+// large uploads.
+func Bind(ctx context.Context, dst interface{}) error {
+	r := Request(ctx)
+	if r == nil {
+		return ErrNoRequest
+	}
+
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind destination must be a pointer to a struct, not %T", dst)
+	}
+
+	form, err := postForm(ctx, AppFromContext(ctx).maxMultipartMemory())
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return newInitError("form", http.StatusRequestEntityTooLarge, err)
+		}
+		return &BindError{Fields: []FieldError{{Source: "form", Err: err}}}
+	}
+
+	b := binder{r: r, form: form}
+	b.bindStruct(v.Elem())
+	if len(b.errs) > 0 {
+		return &BindError{Fields: b.errs}
+	}
+	return nil
+}
+
+type binder struct {
+	r    *http.Request
+	form url.Values
+	errs []FieldError
+}
+
+func (b *binder) bindStruct(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		source, name := bindTag(f)
+		if source == "" {
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				b.bindStruct(v.Field(i))
+			}
+			continue
+		}
+
+		vals := b.values(source, name)
+		if len(vals) == 0 {
+			continue
+		}
+		if err := setField(v.Field(i), vals); err != nil {
+			b.errs = append(b.errs, FieldError{Source: source, Name: name, Value: strings.Join(vals, ","), Err: err})
+		}
+	}
+}
+
+func bindTag(f reflect.StructField) (source, name string) {
+	for _, source := range bindSources {
+		if name, ok := f.Tag.Lookup(source); ok && name != "" && name != "-" {
+			return source, name
+		}
+	}
+	return "", ""
+}
+
+func (b *binder) values(source, name string) []string {
+	switch source {
+	case "path":
+		if v, ok := mux.Vars(b.r)[name]; ok {
+			return []string{v}
+		}
+	case "query":
+		return b.r.URL.Query()[name]
+	case "form":
+		return b.form[name]
+	case "header":
+		return b.r.Header.Values(name)
+	case "cookie":
+		var vals []string
+		for _, c := range b.r.Cookies() {
+			if c.Name == name {
+				vals = append(vals, c.Value)
+			}
+		}
+		return vals
+	}
+	return nil
+}
+
+// postForm returns the form values from the body of the request, for both url encoded and multipart forms
+func postForm(ctx context.Context, maxMemory int64) (url.Values, error) {
+	r, err := parseForm(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mt, _, _ := mime.ParseMediaType(r.Header.Get(HeaderContentType))
+	if mt != MIMEMultipartForm.String() {
+		return r.PostForm, nil
+	}
+	if r.MultipartForm == nil {
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			return nil, err
+		}
+	}
+	return r.MultipartForm.Value, nil
+}
@@ -0,0 +1,83 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBindPage struct {
+	Page int `query:"page"`
+}
+
+type testBind struct {
+	testBindPage
+	ID      int64         `path:"id"`
+	Tags    []string      `query:"tag"`
+	Active  *bool         `query:"active"`
+	Since   time.Time     `query:"since"`
+	Timeout time.Duration `query:"timeout"`
+	Name    string        `form:"name"`
+	Token   string        `header:"X-Token"`
+	Session string        `cookie:"session"`
+	Ignored string
+}
+
+func bindRequest(urlStr string, form url.Values, dst interface{}) error {
+	var bindErr error
+	r := NewRouter()
+	r.POST("/users/{id}", func(ctx context.Context) error {
+		bindErr = Bind(ctx, dst)
+		return nil
+	})
+
+	req := httptest.NewRequest("POST", urlStr, strings.NewReader(form.Encode()))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm.String())
+	req.Header.Set("X-Token", "secret")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "abc"})
+	r.ServeHTTP(httptest.NewRecorder(), req)
+	return bindErr
+}
+
+func TestBind(t *testing.T) {
+	var got testBind
+	err := bindRequest("/users/42?page=3&tag=a&tag=b&active=true&since=2017-01-02T03:04:05Z&timeout=5s&Ignored=x", url.Values{"name": {"foo"}}, &got)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, got.Page)
+	assert.Equal(t, int64(42), got.ID)
+	assert.Equal(t, []string{"a", "b"}, got.Tags)
+	if assert.NotNil(t, got.Active) {
+		assert.True(t, *got.Active)
+	}
+	assert.Equal(t, time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC), got.Since)
+	assert.Equal(t, 5*time.Second, got.Timeout)
+	assert.Equal(t, "foo", got.Name)
+	assert.Equal(t, "secret", got.Token)
+	assert.Equal(t, "abc", got.Session)
+	assert.Equal(t, "", got.Ignored)
+}
+
+func TestBindErrors(t *testing.T) {
+	var got testBind
+	err := bindRequest("/users/abc?page=two&active=maybe", nil, &got)
+
+	var bindErr *BindError
+	if assert.True(t, errors.As(err, &bindErr)) {
+		assert.Equal(t, http.StatusBadRequest, bindErr.Code())
+		if assert.Len(t, bindErr.Fields, 3) {
+			assert.Equal(t, "page", bindErr.Fields[0].Name)
+			assert.Equal(t, "two", bindErr.Fields[0].Value)
+			assert.Equal(t, "path", bindErr.Fields[1].Source)
+			assert.Equal(t, "id", bindErr.Fields[1].Name)
+			assert.Equal(t, "active", bindErr.Fields[2].Name)
+		}
+	}
+}
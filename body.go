@@ -0,0 +1,166 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+var (
+	// ErrBodyStreamed is returned when reading the buffered body after it has been streamed with BodyReader()
+	ErrBodyStreamed = errors.New("request body has already been streamed")
+)
+
+// requestBody reads the request body on first use, so handlers which stream or reject the
+// body don't have to hold all of it in memory.
+type requestBody struct {
+	mu       sync.Mutex
+	r        *http.Request
+	read     bool
+	streamed bool
+	data     []byte
+	err      error
+
+	formParsed bool
+	formErr    error
+}
+
+func (b *requestBody) bytes() ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.streamed {
+		return nil, ErrBodyStreamed
+	}
+	if b.read {
+		return b.data, b.err
+	}
+
+	b.read = true
+	if b.r.Body == nil {
+		return b.data, nil
+	}
+
+	b.data, b.err = ioutil.ReadAll(b.r.Body)
+	b.r.Body.Close()
+	// Reset the body so it can be read again.
+	b.r.Body = ioutil.NopCloser(bytes.NewReader(b.data))
+	if b.err != nil {
+		b.err = newInitError("body", http.StatusBadRequest, b.err)
+	}
+	return b.data, b.err
+}
+
+// parseForm parses the url encoded form body of r once, keeping the error for later calls
+func (b *requestBody) parseForm(r *http.Request) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.formParsed {
+		b.formParsed = true
+		if err := r.ParseForm(); err != nil {
+			b.formErr = newInitError("form", http.StatusBadRequest, err)
+		}
+	}
+	return b.formErr
+}
+
+func (b *requestBody) reader() io.Reader {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.read {
+		return bytes.NewReader(b.data)
+	}
+	b.streamed = true
+	if b.r.Body == nil {
+		return bytes.NewReader(nil)
+	}
+	return bodyReader{b.r.Body}
+}
+
+// bodyReader converts read errors into an *InitError, so an oversized body results in a 413
+type bodyReader struct {
+	io.Reader
+}
+
+func (r bodyReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		err = newInitError("body", http.StatusBadRequest, err)
+	}
+	return n, err
+}
+
+func setBody(ctx context.Context) context.Context {
+	return setValue(ctx, ContextKeyRequestBody, &requestBody{r: Request(ctx)})
+}
+
+func getBody(ctx context.Context) *requestBody {
+	b, _ := Value[*requestBody](ctx, ContextKeyRequestBody)
+	return b
+}
+
+// ReadBody reads the request body, buffering it so that it's only read once. If the body
+// is larger than the limit set with MaxBodySize() or LimitBody() the error is an *InitError
+// with a 413 status code.
+func ReadBody(ctx context.Context) ([]byte, error) {
+	b := getBody(ctx)
+	if b == nil {
+		return nil, errors.New("no request body")
+	}
+	return b.bytes()
+}
+
+// BodyReader returns a reader for the request body which is not buffered. After it's been
+// called, the body is no longer available from Body(), ReadBody() or Decode() unless it had
+// already been read.
+func BodyReader(ctx context.Context) io.Reader {
+	b := getBody(ctx)
+	if b == nil {
+		return bytes.NewReader(nil)
+	}
+	return b.reader()
+}
+
+// MaxBodySize returns middleware which limits the request body of every request it handles
+// to n bytes. It can be used with the mux.Router.Use() func to set a limit for a router.
+// Reading past the limit results in a 413 response.
+func MaxBodySize(n int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// LimitBody returns an AppHandler which limits the request body of a single route to n bytes.
+// It needs to come before any handlers in the chain which read the body.
+func LimitBody(n int64) AppHandler {
+	return func(ctx context.Context) error {
+		b := getBody(ctx)
+		if b == nil {
+			return nil
+		}
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.read {
+			if int64(len(b.data)) > n {
+				return &InitError{Op: "body", Status: http.StatusRequestEntityTooLarge, Err: &http.MaxBytesError{Limit: n}}
+			}
+			return nil
+		}
+		if b.r.Body != nil {
+			b.r.Body = http.MaxBytesReader(ResponseWriter(ctx), b.r.Body, n)
+		}
+		return nil
+	}
+}
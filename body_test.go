@@ -0,0 +1,107 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLazy(t *testing.T) {
+	r := httptest.NewRequest("PUT", "/", strings.NewReader(`{"foo":"bar"}`))
+	ctx := Init(httptest.NewRecorder(), r)
+
+	var dst struct{ Foo string }
+	assert.NoError(t, Decode(ctx, &dst))
+	assert.Equal(t, "bar", dst.Foo)
+	assert.Equal(t, `{"foo":"bar"}`, BodyString(ctx))
+
+	// The body is reset so it can be read again after buffering.
+	b, err := ioutil.ReadAll(Request(ctx).Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, string(b))
+}
+
+func TestBodyReader(t *testing.T) {
+	ctx := Init(httptest.NewRecorder(), httptest.NewRequest("PUT", "/", strings.NewReader("streamed")))
+
+	b, err := ioutil.ReadAll(BodyReader(ctx))
+	assert.NoError(t, err)
+	assert.Equal(t, "streamed", string(b))
+
+	_, err = ReadBody(ctx)
+	assert.Equal(t, ErrBodyStreamed, err)
+	assert.Equal(t, []byte{}, Body(ctx))
+}
+
+func TestMaxBodySize(t *testing.T) {
+	var gotErr error
+	h := MaxBodySize(5)(Handler(func(ctx context.Context) error {
+		_, gotErr = ReadBody(ctx)
+		return gotErr
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("PUT", "/", strings.NewReader("0123456789")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	var initErr *InitError
+	if assert.True(t, errors.As(gotErr, &initErr)) {
+		assert.Equal(t, "body", initErr.Op)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("PUT", "/", strings.NewReader("01234")))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLimitBody(t *testing.T) {
+	h := Handler(LimitBody(5), func(ctx context.Context) error {
+		_, err := ioutil.ReadAll(BodyReader(ctx))
+		return err
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("PUT", "/", strings.NewReader("0123456789")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("PUT", "/", strings.NewReader("0123")))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLimitBodyForm(t *testing.T) {
+	var value string
+	var decodeErr error
+	h := Handler(LimitBody(10), func(ctx context.Context) error {
+		value = FormValue(ctx, "name")
+		var dst struct {
+			Name string `form:"name"`
+		}
+		decodeErr = Decode(ctx, &dst)
+		return decodeErr
+	})
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader("name="+strings.Repeat("a", 1000)))
+	r.Header.Set(HeaderContentType, MIMEApplicationForm.String())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, "", value)
+	var initErr *InitError
+	if assert.True(t, errors.As(decodeErr, &initErr)) {
+		assert.Equal(t, "form", initErr.Op)
+	}
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader("name=a"))
+	r.Header.Set(HeaderContentType, MIMEApplicationForm.String())
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "a", value)
+}
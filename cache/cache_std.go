@@ -3,7 +3,8 @@
 package cache
 
 import (
-	"github.com/bradberger/context"
+	"context"
+
 	"github.com/bradberger/gocache"
 	"github.com/bradberger/gocache/cache"
 	"github.com/bradberger/gocache/drivers/lru"
@@ -0,0 +1,19 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+)
+
+// Compat adapts a handler written for another context type, like the Context of the
+// github.com/bradberger/context package, to an AppHandler. C must be an interface type
+// which the standard context.Context satisfies.
+func Compat[C context.Context](fn func(ctx C) error) AppHandler {
+	return func(ctx context.Context) error {
+		c, ok := ctx.(C)
+		if !ok {
+			return fmt.Errorf("context of type %T is not compatible with the handler", ctx)
+		}
+		return fn(c)
+	}
+}
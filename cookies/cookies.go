@@ -2,10 +2,10 @@
 package cookies
 
 import (
+	"context"
 	"net/http"
 	"os"
 
-	"github.com/bradberger/context"
 	"github.com/bradberger/rest"
 
 	"github.com/gorilla/securecookie"
@@ -43,6 +43,9 @@ func Init(hashKey, blockKey []byte) {
 // Set stores the given value with the given key
 func Set(ctx context.Context, key string, value interface{}) error {
 	w := rest.ResponseWriter(ctx)
+	if w == nil {
+		return rest.ErrNoResponseWriter
+	}
 	encoded, err := jar.Encode(key, value)
 	if err != nil {
 		return err
@@ -60,6 +63,9 @@ func Set(ctx context.Context, key string, value interface{}) error {
 // Get retrieves a cookie with the given key and encodes it into value
 func Get(ctx context.Context, key string, value interface{}) error {
 	r := rest.Request(ctx)
+	if r == nil {
+		return rest.ErrNoRequest
+	}
 	cookie, err := r.Cookie(key)
 	if err != nil {
 		return err
@@ -72,6 +78,9 @@ func Get(ctx context.Context, key string, value interface{}) error {
 
 func Delete(ctx context.Context, key string) error {
 	w := rest.ResponseWriter(ctx)
+	if w == nil {
+		return rest.ErrNoResponseWriter
+	}
 	http.SetCookie(w, &http.Cookie{
 		Name:   key,
 		Path:   "/",
@@ -82,6 +91,9 @@ func Delete(ctx context.Context, key string) error {
 
 func Clear(ctx context.Context) error {
 	r := rest.Request(ctx)
+	if r == nil {
+		return rest.ErrNoRequest
+	}
 	for _, cookie := range r.Cookies() {
 		if err := Delete(ctx, cookie.Name); err != nil {
 			return err
@@ -1,15 +1,13 @@
 package rest
 
 import (
+	"context"
 	"net/http"
-
-	"github.com/bradberger/context"
-
-	"google.golang.org/appengine"
-	"google.golang.org/appengine/datastore"
 )
 
-// OnPanic when defined, will handle any panics from resulting funcs
+// OnPanic when defined, will handle any panics from resulting funcs. The recovered value and
+// stack trace are available with Recovered(ctx) and Stack(ctx). If it returns an error, that
+// error is passed on to OnError.
 var OnPanic AppHandler
 
 // Namespace enables setting custom namespace
@@ -22,36 +20,22 @@ var OnUnauthorized http.HandlerFunc = func(w http.ResponseWriter, r *http.Reques
 	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 }
 
-// OnError is a custom error handler definition. By default it simple returns an http.Error()
-// with the given code and status text.
+// OnError is a custom error handler definition. By default it logs the error and writes it
+// to the response with WriteError().
 var OnError func(ctx context.Context, code int, err error) = func(ctx context.Context, code int, err error) {
-	Errorf(ctx, "error: %v", err)
-	http.Error(ResponseWriter(ctx), err.Error(), code)
+	AppFromContext(ctx).errorf()(ctx, "error: %v", err)
+	if ResponseWriter(ctx) != nil {
+		WriteError(ctx, code, err)
+	}
 }
 
 // GetErrorCode allows customizing of the http.StatusCode for any given error. If the code has already
-// been set with SetCode() then it will not be overwritten by this function.
-var GetErrorCode func(ctx context.Context, err error) int = func(ctx context.Context, err error) (code int) {
-
-	exist := GetCode(ctx)
-	if exist != http.StatusOK {
-		return exist
-	}
-
-	switch err.(type) {
-	case StatusCode:
-		return err.(StatusCode).Code()
-	}
-
-	switch {
-	case err == datastore.ErrNoSuchEntity:
-		code = http.StatusNotFound
-	case appengine.IsOverQuota(err):
-		code = http.StatusTooManyRequests
-	case appengine.IsTimeoutError(err):
-		code = http.StatusGatewayTimeout
-	default:
-		code = http.StatusInternalServerError
+// been set with SetCode() then it will not be overwritten by this function. Otherwise the code comes
+// from the ErrorCodes registry of the App handling the request, then from the first error in the chain
+// which implements ResponseCode, and is 500 if nothing matches.
+var GetErrorCode func(ctx context.Context, err error) int = func(ctx context.Context, err error) int {
+	if code := GetCode(ctx); code != http.StatusOK {
+		return code
 	}
-	return
+	return errorCode(AppFromContext(ctx).errorCodes(), err)
 }
@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Decoder decodes the body of the request in ctx into dst
+type Decoder func(ctx context.Context, dst interface{}) error
+
+// DecodeError is returned when the request body can't be decoded into the destination.
+// For JSON decoded in strict mode, Pointer is the RFC 6901 JSON pointer of the bad value,
+// Offset the byte offset in the body where the error was found, and Expected the Go type
+// the value couldn't be decoded into.
+type DecodeError struct {
+	Field    string
+	Pointer  string
+	Offset   int64
+	Expected string
+	Err      error
+}
+
+func (e *DecodeError) Error() string {
+	switch {
+	case e.Pointer != "":
+		return fmt.Sprintf("decode %s: %v", e.Pointer, e.Err)
+	case e.Field != "":
+		return fmt.Sprintf("decode %s: %v", e.Field, e.Err)
+	default:
+		return fmt.Sprintf("decode: %v", e.Err)
+	}
+}
+
+// Unwrap returns the underlying error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns a 400 status code, which implements the ResponseCode interface
+func (e *DecodeError) Code() int {
+	return http.StatusBadRequest
+}
+
+// MaxMultipartMemory is the number of bytes of a multipart form which are kept in memory
+// when decoding, the rest is stored in temporary files. App.MaxMultipartMemory overrides it.
+var MaxMultipartMemory int64 = 32 << 20
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[MIME]Decoder{
+		MIMEApplicationJSON: decodeJSON,
+		MIMEApplicationXML:  decodeXML,
+		MIMETextXML:         decodeXML,
+		MIMEApplicationForm: decodeForm,
+		MIMEMultipartForm:   decodeMultipart,
+	}
+)
+
+// RegisterDecoder registers the decoder used by Decode() for the given content-type. Content-type
+// parameters like the charset are ignored, so m should only be the media type.
+func RegisterDecoder(m MIME, d Decoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[m] = d
+}
+
+func getDecoder(m MIME) (Decoder, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+
+	if d, ok := decoders[m]; ok {
+		return d, true
+	}
+	// Structured syntax suffixes, like application/vnd.api+json
+	switch {
+	case strings.HasSuffix(string(m), "+json"):
+		d, ok := decoders[MIMEApplicationJSON]
+		return d, ok
+	case strings.HasSuffix(string(m), "+xml"):
+		d, ok := decoders[MIMEApplicationXML]
+		return d, ok
+	}
+	return nil, false
+}
+
+// Decode decodes the http request body into the dst variable, using the decoder registered
+// for the request content-type. JSON is assumed if there's no content-type, and an unknown
+// content-type results in a StatusUnsupportedMediaType error.
+func Decode(ctx context.Context, dst interface{}) error {
+	m := MIMEApplicationJSON
+	if ct := Headers(ctx).Get(HeaderContentType); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return StatusUnsupportedMediaType
+		}
+		m = MIME(mt)
+	}
+
+	d, ok := getDecoder(m)
+	if !ok {
+		return StatusUnsupportedMediaType
+	}
+	return d(ctx, dst)
+}
+
+func decodeJSON(ctx context.Context, dst interface{}) error {
+	b, err := ReadBody(ctx)
+	if err != nil {
+		return err
+	}
+	app := AppFromContext(ctx)
+	if strict, _ := Value[bool](ctx, contextKeyStrict); strict || app.strictDecoding() {
+		return unmarshalJSONStrict(b, dst, app.maxDecodeDepth())
+	}
+	if err := json.Unmarshal(b, dst); err != nil {
+		return jsonDecodeError(b, err)
+	}
+	return nil
+}
+
+func decodeXML(ctx context.Context, dst interface{}) error {
+	b, err := ReadBody(ctx)
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(b, dst); err != nil {
+		return &DecodeError{Err: err}
+	}
+	return nil
+}
+
+// decodeForm decodes the url encoded form. If the body is too large, the error is an
+// *InitError with a 413 status code.
+func decodeForm(ctx context.Context, dst interface{}) error {
+	r, err := parseForm(ctx)
+	if err != nil {
+		var initErr *InitError
+		if errors.As(err, &initErr) && initErr.Status == http.StatusBadRequest {
+			return &DecodeError{Err: initErr.Err}
+		}
+		return err
+	}
+	return decodeValues(r.PostForm, dst, "form")
+}
+
+func decodeMultipart(ctx context.Context, dst interface{}) error {
+	r := Request(ctx)
+	if r == nil {
+		return ErrNoRequest
+	}
+	if err := r.ParseMultipartForm(AppFromContext(ctx).maxMultipartMemory()); err != nil {
+		return &DecodeError{Err: err}
+	}
+	return decodeValues(r.MultipartForm.Value, dst, "form")
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testDecode struct {
+	Name    string    `json:"name" xml:"name"`
+	Age     int       `json:"age" xml:"age"`
+	Admin   bool      `json:"admin" xml:"admin"`
+	Tags    []string  `json:"tags" xml:"tags"`
+	Created time.Time `json:"created" form:"created_at" xml:"created"`
+	Skip    string    `json:"-" xml:"-"`
+}
+
+func TestDecodeContentTypes(t *testing.T) {
+	created := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	want := testDecode{Name: "foo", Age: 42, Admin: true, Tags: []string{"a", "b"}, Created: created}
+
+	r, err := TestPostJSON("/", &want)
+	assert.NoError(t, err)
+	var got testDecode
+	assert.NoError(t, Decode(r.Context, &got))
+	assert.Equal(t, want, got)
+
+	r, err = TestPostXML("/", &want)
+	assert.NoError(t, err)
+	got = testDecode{}
+	assert.NoError(t, Decode(r.Context, &got))
+	assert.Equal(t, want, got)
+
+	r, err = TestPostForm("/", url.Values{
+		"name":       {"foo"},
+		"age":        {"42"},
+		"admin":      {"true"},
+		"tags":       {"a", "b"},
+		"created_at": {"2017-01-02T03:04:05Z"},
+		"Skip":       {"skipped"},
+	})
+	assert.NoError(t, err)
+	got = testDecode{}
+	assert.NoError(t, Decode(r.Context, &got))
+	assert.Equal(t, want, got)
+}
+
+func TestDecodeMultipart(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("name", "foo")
+	mw.WriteField("age", "42")
+	mw.Close()
+
+	r, err := TestPost("/", mw.FormDataContentType(), &buf)
+	assert.NoError(t, err)
+
+	var got testDecode
+	assert.NoError(t, Decode(r.Context, &got))
+	assert.Equal(t, "foo", got.Name)
+	assert.Equal(t, 42, got.Age)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	r, err := TestPost("/", "application/x-unknown", strings.NewReader("foo"))
+	assert.NoError(t, err)
+	var got testDecode
+	assert.Equal(t, StatusUnsupportedMediaType, Decode(r.Context, &got))
+
+	r, err = TestPostForm("/", url.Values{"age": {"old"}})
+	assert.NoError(t, err)
+	err = Decode(r.Context, &got)
+	var decodeErr *DecodeError
+	if assert.True(t, errors.As(err, &decodeErr)) {
+		assert.Equal(t, "age", decodeErr.Field)
+		assert.Equal(t, 400, decodeErr.Code())
+	}
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	for _, ct := range []string{"application/json", "application/xml"} {
+		r, err := TestPost("/", ct, strings.NewReader("{not json"))
+		assert.NoError(t, err)
+		var got testDecode
+		err = Decode(r.Context, &got)
+		var decodeErr *DecodeError
+		assert.True(t, errors.As(err, &decodeErr), ct)
+		assert.Equal(t, 400, GetErrorCode(r.Context, err), ct)
+	}
+}
+
+func TestDecodeSuffix(t *testing.T) {
+	r, err := TestPost("/", "application/vnd.api+json; charset=utf-8", strings.NewReader(`{"name":"foo"}`))
+	assert.NoError(t, err)
+	var got testDecode
+	assert.NoError(t, Decode(r.Context, &got))
+	assert.Equal(t, "foo", got.Name)
+}
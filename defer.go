@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+	"sync"
+)
+
+// AfterFunc is run after the response has been written, with the final status code and the
+// error returned by the handler chain. If a handler panicked, err is a *PanicError. If the
+// route timed out, the code is 503 and err is http.ErrHandlerTimeout unless the chain
+// returned an error.
+type AfterFunc func(ctx context.Context, code int, err error)
+
+type deferred struct {
+	mu  sync.Mutex
+	fns []AfterFunc
+}
+
+// Defer registers fn to be run after the response has been written. Like the defer statement,
+// funcs are run in the reverse order they were registered. They're always run, even if a
+// handler returns an error or panics, but only for requests served by Handler().
+func Defer(ctx context.Context, fn AfterFunc) {
+	d, ok := Value[*deferred](ctx, contextKeyDeferred)
+	if !ok {
+		return
+	}
+	d.mu.Lock()
+	d.fns = append(d.fns, fn)
+	d.mu.Unlock()
+}
+
+func runDeferred(ctx context.Context, err error) {
+	d, ok := Value[*deferred](ctx, contextKeyDeferred)
+	if !ok {
+		return
+	}
+
+	d.mu.Lock()
+	fns := d.fns
+	d.fns = nil
+	d.mu.Unlock()
+
+	code := GetCode(ctx)
+	if timedOut(ctx) {
+		code = http.StatusServiceUnavailable
+		if err == nil {
+			err = http.ErrHandlerTimeout
+		}
+	}
+	for i := len(fns) - 1; i >= 0; i-- {
+		runAfterFunc(ctx, fns[i], code, err)
+	}
+}
+
+// timedOut reports whether the request was for a route with a Router.Timeout, and the timeout
+// has passed, in which case the client has been sent a 503 by http.TimeoutHandler.
+func timedOut(ctx context.Context) bool {
+	timeout, _ := Value[bool](ctx, contextKeyTimeout)
+	return timeout && ctx.Err() == context.DeadlineExceeded
+}
+
+func runAfterFunc(ctx context.Context, fn AfterFunc, code int, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			AppFromContext(ctx).criticalf()(ctx, "panic in deferred func: %v\n%s", v, debug.Stack())
+		}
+	}()
+	fn(ctx, code, err)
+}
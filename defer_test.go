@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type afterCall struct {
+	name string
+	code int
+	err  error
+}
+
+func recordAfter(calls *[]afterCall, name string) AfterFunc {
+	return func(ctx context.Context, code int, err error) {
+		*calls = append(*calls, afterCall{name, code, err})
+	}
+}
+
+func TestDefer(t *testing.T) {
+	var calls []afterCall
+	h := Handler(func(ctx context.Context) error {
+		Defer(ctx, recordAfter(&calls, "first"))
+		Defer(ctx, recordAfter(&calls, "second"))
+		return Text(ctx, http.StatusCreated, "ok")
+	})
+
+	serve(h, "GET", "/")
+	assert.Equal(t, []afterCall{{"second", http.StatusCreated, nil}, {"first", http.StatusCreated, nil}}, calls)
+}
+
+func TestDeferError(t *testing.T) {
+	var calls []afterCall
+	h := Handler(func(ctx context.Context) error {
+		Defer(ctx, recordAfter(&calls, "error"))
+		return StatusNotFound
+	})
+
+	serve(h, "GET", "/")
+	assert.Equal(t, []afterCall{{"error", http.StatusNotFound, StatusNotFound}}, calls)
+}
+
+func TestDeferPanic(t *testing.T) {
+	var calls []afterCall
+	h := Handler(func(ctx context.Context) error {
+		Defer(ctx, func(ctx context.Context, code int, err error) {
+			panic("hook")
+		})
+		Defer(ctx, recordAfter(&calls, "panic"))
+		panic("boom")
+	})
+
+	serve(h, "GET", "/")
+	if assert.Len(t, calls, 1) {
+		assert.Equal(t, http.StatusInternalServerError, calls[0].code)
+		var perr *PanicError
+		assert.True(t, errors.As(calls[0].err, &perr))
+	}
+}
+
+func TestRouterAfter(t *testing.T) {
+	var calls []afterCall
+	r := NewRouter()
+	r.After(recordAfter(&calls, "router"))
+	r.GET("/", func(ctx context.Context) error {
+		Defer(ctx, recordAfter(&calls, "handler"))
+		return nil
+	})
+
+	serve(r, "GET", "/?q=%zz")
+	if assert.Len(t, calls, 1) {
+		assert.Equal(t, http.StatusBadRequest, calls[0].code)
+		var initErr *InitError
+		assert.True(t, errors.As(calls[0].err, &initErr))
+	}
+
+	calls = nil
+	serve(r, "GET", "/")
+	assert.Equal(t, []afterCall{{"handler", http.StatusOK, nil}, {"router", http.StatusOK, nil}}, calls)
+}
@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// ErrorCodes is the registry used by the default GetErrorCode to map errors to status codes
+var ErrorCodes = NewErrorRegistry()
+
+// ErrorRegistry maps errors to HTTP status codes. Errors are matched with errors.Is() and
+// errors.As(), so wrapped errors match too. It's safe for concurrent use.
+type ErrorRegistry struct {
+	mu      sync.RWMutex
+	entries []errorEntry
+}
+
+type errorEntry struct {
+	match func(error) bool
+	code  int
+}
+
+// NewErrorRegistry returns an empty ErrorRegistry
+func NewErrorRegistry() *ErrorRegistry {
+	return &ErrorRegistry{}
+}
+
+// Register maps the sentinel error, and any error which wraps it, to the status code
+func (r *ErrorRegistry) Register(target error, code int) {
+	r.RegisterFunc(func(err error) bool {
+		return errors.Is(err, target)
+	}, code)
+}
+
+// RegisterFunc maps errors for which fn returns true to the status code. fn is called with
+// the error as it was returned, so it should unwrap the error itself if it needs to.
+func (r *ErrorRegistry) RegisterFunc(fn func(err error) bool, code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, errorEntry{match: fn, code: code})
+}
+
+// RegisterStd adds mappings for common standard library errors: sql.ErrNoRows and
+// os.ErrNotExist to 404 and context.DeadlineExceeded to 504.
+func (r *ErrorRegistry) RegisterStd() {
+	r.Register(sql.ErrNoRows, http.StatusNotFound)
+	r.Register(os.ErrNotExist, http.StatusNotFound)
+	r.Register(context.DeadlineExceeded, http.StatusGatewayTimeout)
+}
+
+// Code returns the status code for the first mapping which matches err, in the order they
+// were registered. It returns false if nothing matches.
+func (r *ErrorRegistry) Code(err error) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, e := range r.entries {
+		if e.match(err) {
+			return e.code, true
+		}
+	}
+	return 0, false
+}
+
+// RegisterErrorType maps errors of type T, and any error which wraps one, to the status code
+func RegisterErrorType[T error](r *ErrorRegistry, code int) {
+	r.RegisterFunc(func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}, code)
+}
+
+// errorCode returns the code from the registry, or the code of the first error in the chain
+// which implements ResponseCode, or 500. The registry is checked first so that apps can
+// remap errors which have their own code, like *DecodeError.
+func errorCode(reg *ErrorRegistry, err error) int {
+	if code, ok := reg.Code(err); ok {
+		return code
+	}
+	var rc ResponseCode
+	if errors.As(err, &rc) {
+		return rc.Code()
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTypedError struct {
+	msg string
+}
+
+func (e *testTypedError) Error() string {
+	return e.msg
+}
+
+func TestErrorRegistry(t *testing.T) {
+	errGone := errors.New("gone")
+
+	reg := NewErrorRegistry()
+	reg.Register(errGone, http.StatusGone)
+	RegisterErrorType[*testTypedError](reg, http.StatusTeapot)
+	reg.RegisterFunc(func(err error) bool {
+		return err.Error() == "slow down"
+	}, http.StatusTooManyRequests)
+
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{errGone, http.StatusGone},
+		{fmt.Errorf("load: %w", errGone), http.StatusGone},
+		{fmt.Errorf("load: %w", &testTypedError{"typed"}), http.StatusTeapot},
+		{errors.New("slow down"), http.StatusTooManyRequests},
+		{fmt.Errorf("lookup: %w", StatusNotFound), http.StatusNotFound},
+		{fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", &ParamError{Name: "id"})), http.StatusBadRequest},
+		{errors.Join(errors.New("other"), StatusConflict), http.StatusConflict},
+		{sql.ErrNoRows, http.StatusInternalServerError},
+		{errors.New("unknown"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, errorCode(reg, tt.err), tt.err.Error())
+	}
+
+	reg.RegisterStd()
+	assert.Equal(t, http.StatusNotFound, errorCode(reg, fmt.Errorf("query: %w", sql.ErrNoRows)))
+	_, err := os.Open("/does/not/exist")
+	assert.Equal(t, http.StatusNotFound, errorCode(reg, err))
+	assert.Equal(t, http.StatusGatewayTimeout, errorCode(reg, context.DeadlineExceeded))
+}
+
+func TestGetErrorCodeWrapped(t *testing.T) {
+	w := serve(Handler(func(ctx context.Context) error {
+		return fmt.Errorf("find user: %w", StatusNotFound)
+	}), "GET", "/")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestErrorRegistryOverridesResponseCode(t *testing.T) {
+	reg := NewErrorRegistry()
+	RegisterErrorType[*DecodeError](reg, http.StatusUnprocessableEntity)
+	app := &App{ErrorCodes: reg}
+
+	w := serve(app.Handler(func(ctx context.Context) error {
+		return fmt.Errorf("decode user: %w", &DecodeError{Field: "age", Err: errors.New("bad")})
+	}), "GET", "/")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	// Errors the registry doesn't match still use their own code
+	w = serve(app.Handler(func(ctx context.Context) error {
+		return &ParamError{Name: "id"}
+	}), "GET", "/")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -1,7 +1,7 @@
 package rest
 
 import (
-	"github.com/bradberger/context"
+	"context"
 )
 
 // LogFunc is a custom log function type
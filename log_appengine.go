@@ -3,7 +3,7 @@
 package rest
 
 import (
-	"github.com/bradberger/context"
+	"context"
 
 	"google.golang.org/appengine/log"
 )
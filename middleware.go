@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+)
+
+// chain keeps track of the position in a chain of AppHandlers, so that FromMiddleware()
+// can run the rest of the chain inside of the middleware.
+type chain struct {
+	fns []AppHandler
+	pos int
+}
+
+// handledError is an error which has already been passed to OnError
+type handledError struct {
+	error
+}
+
+func runChain(ctx context.Context, fns []AppHandler) error {
+	c := &chain{fns: fns}
+	ctx = setValue(ctx, contextKeyChain, c)
+	for c.pos < len(c.fns) {
+		fn := c.fns[c.pos]
+		c.pos++
+		if err := fn(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FromMiddleware adapts net/http middleware to an AppHandler. The rest of the Handler chain
+// runs as the next http.Handler of the middleware, with a context which keeps the values
+// set by the middleware as well as the rest values. If the middleware doesn't call the
+// next handler, the rest of the chain is skipped.
+func FromMiddleware(mw func(http.Handler) http.Handler) AppHandler {
+	return func(ctx context.Context) error {
+		var rest []AppHandler
+		if c, ok := Value[*chain](ctx, contextKeyChain); ok {
+			rest, c.pos = c.fns[c.pos:], len(c.fns)
+		}
+
+		w, r := ResponseWriter(ctx), Request(ctx)
+		if w == nil || r == nil {
+			return ErrNoRequest
+		}
+
+		var err error
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			nextCtx, initErr := withRequest(r.Context(), w, r)
+			if err = initErr; err == nil {
+				err = runChain(nextCtx, rest)
+			}
+			// Handle errors here so the error response goes through the middleware.
+			if _, handled := err.(handledError); !handled && err != nil {
+				AppFromContext(nextCtx).handleError(nextCtx, err)
+				err = handledError{err}
+			}
+		})
+		mw(next).ServeHTTP(w, r.WithContext(ctx))
+		return err
+	}
+}
+
+// FromHandler adapts a http.Handler to an AppHandler. The request passed to h carries the
+// rest context, so if h is itself a Handler(), it reuses the context rather than reading the
+// request body again.
+func FromHandler(h http.Handler) AppHandler {
+	return func(ctx context.Context) error {
+		w, r := ResponseWriter(ctx), Request(ctx)
+		if w == nil || r == nil {
+			return ErrNoRequest
+		}
+		h.ServeHTTP(w, r.WithContext(ctx))
+		return nil
+	}
+}
+
+// ToMiddleware adapts a chain of AppHandlers to net/http middleware. The next handler is
+// only called if none of the AppHandlers return an error, and its request carries the rest
+// context values. It uses the hooks of DefaultApp.
+func ToMiddleware(fn ...AppHandler) func(http.Handler) http.Handler {
+	return DefaultApp.ToMiddleware(fn...)
+}
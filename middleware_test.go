@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.code = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func TestFromMiddleware(t *testing.T) {
+	var status int
+	mw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := &statusRecorder{ResponseWriter: w}
+			r = r.WithContext(context.WithValue(r.Context(), testContextKey("mw"), "value"))
+			next.ServeHTTP(rec, r)
+			status = rec.code
+		})
+	}
+
+	var got interface{}
+	h := Handler(FromMiddleware(mw), func(ctx context.Context) error {
+		got = ctx.Value(testContextKey("mw"))
+		assert.Equal(t, "bar", FormValue(ctx, "foo"))
+		return StatusTeapot
+	})
+
+	w := serve(h, "GET", "/?foo=bar")
+	assert.Equal(t, "value", got)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, http.StatusTeapot, status)
+}
+
+func TestFromMiddlewareStop(t *testing.T) {
+	mw := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "nope", http.StatusForbidden)
+		})
+	}
+
+	called := false
+	h := Handler(FromMiddleware(mw), func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+
+	assert.Equal(t, http.StatusForbidden, serve(h, "GET", "/").Code)
+	assert.False(t, called)
+}
+
+func TestToMiddleware(t *testing.T) {
+	var body []byte
+	mw := ToMiddleware(func(ctx context.Context) error {
+		body = Body(ctx)
+		return nil
+	})
+
+	var nested []byte
+	h := mw(Handler(func(ctx context.Context) error {
+		nested = Body(ctx)
+		return Text(ctx, http.StatusOK, FormValue(ctx, "foo"))
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("PUT", "/?foo=bar", strings.NewReader("body")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "bar", w.Body.String())
+	assert.Equal(t, "body", string(body))
+	assert.Equal(t, "body", string(nested))
+
+	called := false
+	h = ToMiddleware(func(ctx context.Context) error {
+		return StatusUnauthorized
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	assert.Equal(t, http.StatusUnauthorized, serve(h, "GET", "/").Code)
+	assert.False(t, called)
+}
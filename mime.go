@@ -78,6 +78,8 @@ const (
 	MIMEApplicationForm                  MIME = "application/x-www-form-urlencoded"
 	MIMEApplicationProtobuf              MIME = "application/protobuf"
 	MIMEApplicationMsgpack               MIME = "application/msgpack"
+	MIMEApplicationProblemJSON           MIME = "application/problem+json"
+	MIMEApplicationProblemXML            MIME = "application/problem+xml"
 	MIMETextHTML                         MIME = "text/html"
 	MIMETextHTMLCharsetUTF8              MIME = MIMETextHTML + "; " + charsetUTF8
 	MIMETextPlain                        MIME = "text/plain"
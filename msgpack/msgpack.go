@@ -0,0 +1,76 @@
+// Package msgpack adds MessagePack support to rest. Importing it registers an encoder and
+// decoder for application/msgpack, so Render() and Decode() handle MessagePack, and a decoder
+// for TestRequest.Decode(). Structs are encoded using their json tags.
+package msgpack
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/bradberger/rest"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func init() {
+	rest.RegisterEncoder(rest.MIMEApplicationMsgpack, rest.NewEncoder(rest.MIMEApplicationMsgpack.String(), Encode))
+	rest.RegisterDecoder(rest.MIMEApplicationMsgpack, decode)
+	rest.RegisterResponseDecoder(rest.MIMEApplicationMsgpack, Unmarshal)
+}
+
+// Encode writes data to w as MessagePack. Readers and byte slices are written as they are.
+func Encode(w io.Writer, data interface{}) error {
+	switch v := data.(type) {
+	case io.Reader:
+		_, err := io.Copy(w, v)
+		return err
+	case *[]byte:
+		_, err := w.Write(*v)
+		return err
+	case []byte:
+		_, err := w.Write(v)
+		return err
+	}
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(data)
+}
+
+// Unmarshal decodes the MessagePack data into dst
+func Unmarshal(b []byte, dst interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(b))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(dst)
+}
+
+func decode(ctx context.Context, dst interface{}) error {
+	b, err := rest.ReadBody(ctx)
+	if err != nil {
+		return err
+	}
+	if err := Unmarshal(b, dst); err != nil {
+		return &rest.DecodeError{Err: err}
+	}
+	return nil
+}
+
+// Msgpack writes the MessagePack encoded data to the HTTP connection
+func Msgpack(ctx context.Context, code int, data interface{}) error {
+	w := rest.ResponseWriter(ctx)
+	if w == nil {
+		return rest.ErrNoResponseWriter
+	}
+	w.Header().Set(rest.HeaderContentType, rest.MIMEApplicationMsgpack.String())
+	w.WriteHeader(code)
+	return Encode(w, data)
+}
+
+// TestPostMsgpack bootstraps a POST request to the given urlStr with MessagePack encoded data as the request body
+func TestPostMsgpack(urlStr string, data interface{}) (*rest.TestRequest, error) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, data); err != nil {
+		return nil, err
+	}
+	return rest.TestPost(urlStr, rest.MIMEApplicationMsgpack.String(), &buf)
+}
@@ -0,0 +1,79 @@
+package msgpack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bradberger/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+type testUser struct {
+	Name    string    `json:"name"`
+	Age     int       `json:"age"`
+	Tags    []string  `json:"tags"`
+	Created time.Time `json:"created"`
+	Skip    string    `json:"-"`
+}
+
+func TestDecode(t *testing.T) {
+	want := testUser{Name: "foo", Age: 42, Tags: []string{"a"}, Created: time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)}
+	r, err := TestPostMsgpack("/", &want)
+	assert.NoError(t, err)
+
+	var got testUser
+	assert.NoError(t, rest.Decode(r.Context, &got))
+	assert.Equal(t, want.Name, got.Name)
+	assert.Equal(t, want.Age, got.Age)
+	assert.Equal(t, want.Tags, got.Tags)
+	assert.True(t, want.Created.Equal(got.Created))
+
+	// The json tags are used for the keys
+	var m map[string]interface{}
+	assert.NoError(t, Unmarshal(rest.Body(r.Context), &m))
+	assert.Contains(t, m, "name")
+	assert.NotContains(t, m, "Skip")
+}
+
+func TestMsgpack(t *testing.T) {
+	r, err := TestPostMsgpack("/", &testUser{Name: "foo"})
+	assert.NoError(t, err)
+	assert.NoError(t, r.Do(func(ctx context.Context) error {
+		return Msgpack(ctx, http.StatusCreated, &testUser{Name: "bar"})
+	}))
+
+	var got testUser
+	assert.NoError(t, r.Decode(&got))
+	assert.Equal(t, http.StatusCreated, r.Writer.Code)
+	assert.Equal(t, rest.MIMEApplicationMsgpack.String(), r.Writer.Header().Get(rest.HeaderContentType))
+	assert.Equal(t, "bar", got.Name)
+}
+
+func TestRender(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(rest.HeaderAccept, rest.MIMEApplicationMsgpack.String())
+	rest.Handler(func(ctx context.Context) error {
+		return rest.Render(ctx, http.StatusOK, &testUser{Name: "foo"})
+	}).ServeHTTP(w, req)
+
+	assert.Equal(t, rest.MIMEApplicationMsgpack.String(), w.Header().Get(rest.HeaderContentType))
+	var got testUser
+	assert.NoError(t, Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, "foo", got.Name)
+}
+
+func TestDecodeMalformed(t *testing.T) {
+	r, err := rest.TestPost("/", rest.MIMEApplicationMsgpack.String(), strings.NewReader("{not json"))
+	assert.NoError(t, err)
+	var got testUser
+	err = rest.Decode(r.Context, &got)
+	var decodeErr *rest.DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.Equal(t, http.StatusBadRequest, rest.GetErrorCode(r.Context, err))
+}
@@ -0,0 +1,118 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// EnvironmentDevelopment is the environment in which WriteError() includes the details of 5xx errors
+const EnvironmentDevelopment = "development"
+
+// WithEnvironment returns a context with the environment stored under ContextKeyEnvironment.
+// It can be set on the request context before the request is handled, for example:
+//
+//	r = r.WithContext(rest.WithEnvironment(r.Context(), rest.EnvironmentDevelopment))
+func WithEnvironment(ctx context.Context, env string) context.Context {
+	return setValue(ctx, ContextKeyEnvironment, env)
+}
+
+// Environment returns the environment stored under ContextKeyEnvironment, or an empty string
+func Environment(ctx context.Context) string {
+	env, _ := Value[string](ctx, ContextKeyEnvironment)
+	return env
+}
+
+// WriteError writes the error response used by the default OnError. If the error, or the error
+// in its chain which supplies the status code, implements ResponseReader, ResponseString or
+// ResponseBytes, that body is written with the content-type from ResponseContentType. Otherwise the error is converted with ProblemFrom() and written as
+// JSON, XML, an HTML page or plain text, whichever the request accepts. The details of 5xx
+// errors are hidden unless the environment is EnvironmentDevelopment. If the response has
+// already been written, the error is only logged.
+func WriteError(ctx context.Context, code int, err error) error {
+	w, werr := writer(ctx)
+	if werr != nil {
+		return werr
+	}
+	if Written(ctx) {
+		AppFromContext(ctx).errorf()(ctx, "error after the response was written: %v", err)
+		return nil
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		apiErr.setHeaders(w.Header())
+	}
+
+	if body, v := errorBody(ctx, code, err); v != nil {
+		ct := MIMETextPlainCharsetUTF8.String()
+		if c, ok := v.(ResponseContentType); ok {
+			ct = c.ContentType()
+		}
+		w.Header().Set(HeaderContentType, ct)
+		w.WriteHeader(code)
+		return write(w, body)
+	}
+
+	p := ProblemFrom(code, err)
+	if p.Status != code {
+		cp := *p
+		cp.Status = code
+		p = &cp
+	}
+	if code >= http.StatusInternalServerError && Environment(ctx) == EnvironmentDevelopment {
+		cp := *p
+		if cp.Detail == "" && err != nil {
+			cp.Detail = err.Error()
+		}
+		if stack := Stack(ctx); stack != nil {
+			cp.Extensions = map[string]interface{}{"stack": string(stack)}
+			for k, v := range p.Extensions {
+				cp.Extensions[k] = v
+			}
+		}
+		p = &cp
+	}
+
+	addVary(w.Header(), HeaderAccept)
+	mt, _ := Negotiate(ctx, MIMEApplicationProblemJSON, MIMEApplicationJSON, MIMEApplicationProblemXML, MIMEApplicationXML, MIMETextXML, MIMETextHTML, MIMETextPlain)
+	return writeProblem(w, mt, p)
+}
+
+// errorBody returns the body of the error if it implements ResponseReader, ResponseString or
+// ResponseBytes, and the error the body came from. Only the error itself, or the error in the
+// chain which supplies the status code, is used, so the body of a wrapped cause is never sent.
+// The bodies of 5xx errors are only sent when the environment is EnvironmentDevelopment.
+func errorBody(ctx context.Context, code int, err error) (interface{}, error) {
+	if err == nil || (code >= http.StatusInternalServerError && Environment(ctx) != EnvironmentDevelopment) {
+		return nil, nil
+	}
+	if body := responseBody(err); body != nil {
+		return body, err
+	}
+	var rc ResponseCode
+	if errors.As(err, &rc) {
+		if e, ok := rc.(error); ok {
+			if body := responseBody(e); body != nil {
+				return body, e
+			}
+		}
+	}
+	return nil, nil
+}
+
+// responseBody returns the body of the error, or nil if it doesn't have one. StatusCode,
+// *Problem and *APIError errors are always rendered as problems.
+func responseBody(err error) interface{} {
+	switch b := err.(type) {
+	case StatusCode, *Problem, *APIError:
+		return nil
+	case ResponseReader:
+		return b.Body()
+	case ResponseString:
+		return b.Body()
+	case ResponseBytes:
+		return b.Body()
+	}
+	return nil
+}
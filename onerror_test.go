@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBodyError struct{}
+
+func (testBodyError) Error() string       { return "teapot" }
+func (testBodyError) Code() int           { return http.StatusTeapot }
+func (testBodyError) Body() []byte        { return []byte("<teapot/>") }
+func (testBodyError) ContentType() string { return MIMEApplicationXML.String() }
+
+func TestWriteErrorNegotiation(t *testing.T) {
+	err := errors.New("bad input")
+	tests := []struct {
+		accept, contentType, body string
+	}{
+		{"", MIMEApplicationProblemJSON.String(), `"detail":"bad input"`},
+		{"application/json", MIMEApplicationProblemJSON.String(), `"detail":"bad input"`},
+		{"application/xml", MIMEApplicationProblemXML.String(), `<detail>bad input</detail>`},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", MIMETextHTMLCharsetUTF8.String(), `<p>bad input</p>`},
+		{"text/plain", MIMETextPlainCharsetUTF8.String(), "Bad Request: bad input\n"},
+		{"image/png", MIMEApplicationProblemJSON.String(), `"detail":"bad input"`},
+	}
+	for _, tt := range tests {
+		w := serveError(tt.accept, StatusBadRequest.WithMessage("bad input"))
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.accept)
+		assert.Equal(t, tt.contentType, w.Header().Get(HeaderContentType), tt.accept)
+		assert.Contains(t, w.Body.String(), tt.body, tt.accept)
+
+		w = serveError(tt.accept, &BindError{Fields: []FieldError{{Source: "query", Name: "page", Err: err}}})
+		assert.Equal(t, http.StatusBadRequest, w.Code, tt.accept)
+		assert.Contains(t, w.Body.String(), "page", tt.accept)
+	}
+}
+
+func TestWriteErrorBody(t *testing.T) {
+	w := serveError("application/json", testBodyError{})
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, MIMEApplicationXML.String(), w.Header().Get(HeaderContentType))
+	assert.Equal(t, "<teapot/>", w.Body.String())
+}
+
+func TestWriteErrorEnvironment(t *testing.T) {
+	err := errors.New("connect: db.internal:5432 refused")
+	h := Handler(func(ctx context.Context) error {
+		return err
+	})
+
+	w := serve(h, "GET", "/")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "db.internal")
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r = r.WithContext(WithEnvironment(r.Context(), EnvironmentDevelopment))
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "db.internal")
+}
+
+func TestWriteErrorPanicStack(t *testing.T) {
+	h := Handler(func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r = r.WithContext(WithEnvironment(r.Context(), EnvironmentDevelopment))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "panic: boom")
+	assert.True(t, strings.Contains(w.Body.String(), `"stack":`))
+}
+
+func TestWriteErrorHidesAPIErrorCause(t *testing.T) {
+	err := StatusBadGateway.WithMessage("upstream failed").WithCause(testBodyError{})
+	w := serveError("application/json", fmt.Errorf("call upstream: %w", err))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Equal(t, MIMEApplicationProblemJSON.String(), w.Header().Get(HeaderContentType))
+	assert.NotContains(t, w.Body.String(), "teapot")
+	assert.Contains(t, w.Body.String(), "upstream failed")
+}
+
+type testSecretError struct{}
+
+func (testSecretError) Error() string { return "secret" }
+func (testSecretError) Body() string  { return "upstream said: password=hunter2" }
+
+func TestWriteErrorWrappedBody(t *testing.T) {
+	h := Handler(func(ctx context.Context) error {
+		return fmt.Errorf("calling billing: %w", testSecretError{})
+	})
+	w := serve(h, "GET", "/")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "hunter2")
+
+	// The body of the error supplying the status code is still used
+	w = serveError("application/json", fmt.Errorf("brewing: %w", testBodyError{}))
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "<teapot/>", w.Body.String())
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r = r.WithContext(WithEnvironment(r.Context(), EnvironmentDevelopment))
+	w = httptest.NewRecorder()
+	Handler(func(ctx context.Context) error {
+		return testSecretError{}
+	}).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "upstream said: password=hunter2", w.Body.String())
+}
+
+func TestWriteErrorAfterWritten(t *testing.T) {
+	var logged bool
+	app := &App{Errorf: func(ctx context.Context, format string, args ...interface{}) {
+		logged = true
+	}}
+	w := serve(app.Handler(func(ctx context.Context) error {
+		Text(ctx, http.StatusOK, "partial")
+		return errors.New("failed after writing")
+	}), "GET", "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "partial", w.Body.String())
+	assert.True(t, logged)
+}
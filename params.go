@@ -0,0 +1,238 @@
+package rest
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrParamMissing is the cause of a *ParamError when the parameter isn't set
+	ErrParamMissing = errors.New("missing")
+	// ErrInvalidUUID is the cause of a *ParamError when the parameter isn't a valid UUID
+	ErrInvalidUUID = errors.New("invalid UUID")
+)
+
+// ParamError is returned by the typed parameter funcs when a parameter is missing or
+// can't be converted. It has a 400 status code.
+type ParamError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	if e.Err == ErrParamMissing {
+		return fmt.Sprintf("parameter %q is missing", e.Name)
+	}
+	return fmt.Sprintf("parameter %q is invalid: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns a 400 status code, which implements the ResponseCode interface
+func (e *ParamError) Code() int {
+	return http.StatusBadRequest
+}
+
+// UUID is a 16 byte universally unique identifier
+type UUID [16]byte
+
+// String returns the UUID in the canonical xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx form
+func (u UUID) String() string {
+	var b [36]byte
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b[:])
+}
+
+// MarshalText implements the encoding.TextMarshaler interface
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, so UUIDs can be used with Bind()
+func (u *UUID) UnmarshalText(b []byte) error {
+	id, err := ParseUUID(string(b))
+	if err != nil {
+		return err
+	}
+	*u = id
+	return nil
+}
+
+// ParseUUID parses a UUID in the canonical form, with or without the hyphens or braces
+func ParseUUID(s string) (u UUID, err error) {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	if len(s) == 36 {
+		if s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return u, ErrInvalidUUID
+		}
+		s = s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	}
+	if len(s) != 32 {
+		return u, ErrInvalidUUID
+	}
+	if _, err := hex.Decode(u[:], []byte(s)); err != nil {
+		return u, ErrInvalidUUID
+	}
+	return u, nil
+}
+
+// param returns the value of the parameter, or a *ParamError if it's not set
+func param(ctx context.Context, key string) (string, error) {
+	v := FormValue(ctx, key)
+	if v == "" {
+		return "", &ParamError{Name: key, Err: ErrParamMissing}
+	}
+	return v, nil
+}
+
+func paramError(key, val string, err error) error {
+	if numErr, ok := err.(*strconv.NumError); ok {
+		err = numErr.Err
+	}
+	return &ParamError{Name: key, Value: val, Err: err}
+}
+
+// IntParam returns the parameter as an int
+func IntParam(ctx context.Context, key string) (int, error) {
+	v, err := param(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, paramError(key, v, err)
+	}
+	return n, nil
+}
+
+// IntParamOr returns the parameter as an int, or def if it's not set
+func IntParamOr(ctx context.Context, key string, def int) (int, error) {
+	if FormValue(ctx, key) == "" {
+		return def, nil
+	}
+	return IntParam(ctx, key)
+}
+
+// Int64Param returns the parameter as an int64
+func Int64Param(ctx context.Context, key string) (int64, error) {
+	v, err := param(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, paramError(key, v, err)
+	}
+	return n, nil
+}
+
+// Int64ParamOr returns the parameter as an int64, or def if it's not set
+func Int64ParamOr(ctx context.Context, key string, def int64) (int64, error) {
+	if FormValue(ctx, key) == "" {
+		return def, nil
+	}
+	return Int64Param(ctx, key)
+}
+
+// BoolParam returns the parameter as a bool. It accepts the values strconv.ParseBool() does.
+func BoolParam(ctx context.Context, key string) (bool, error) {
+	v, err := param(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, paramError(key, v, err)
+	}
+	return b, nil
+}
+
+// BoolParamOr returns the parameter as a bool, or def if it's not set
+func BoolParamOr(ctx context.Context, key string, def bool) (bool, error) {
+	if FormValue(ctx, key) == "" {
+		return def, nil
+	}
+	return BoolParam(ctx, key)
+}
+
+// UUIDParam returns the parameter as a UUID
+func UUIDParam(ctx context.Context, key string) (UUID, error) {
+	v, err := param(ctx, key)
+	if err != nil {
+		return UUID{}, err
+	}
+	u, err := ParseUUID(v)
+	if err != nil {
+		return UUID{}, paramError(key, v, err)
+	}
+	return u, nil
+}
+
+// UUIDParamOr returns the parameter as a UUID, or def if it's not set
+func UUIDParamOr(ctx context.Context, key string, def UUID) (UUID, error) {
+	if FormValue(ctx, key) == "" {
+		return def, nil
+	}
+	return UUIDParam(ctx, key)
+}
+
+// TimeParam returns the parameter as a time.Time parsed with the layout
+func TimeParam(ctx context.Context, key, layout string) (time.Time, error) {
+	v, err := param(ctx, key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(layout, v)
+	if err != nil {
+		return time.Time{}, paramError(key, v, err)
+	}
+	return t, nil
+}
+
+// TimeParamOr returns the parameter as a time.Time parsed with the layout, or def if it's not set
+func TimeParamOr(ctx context.Context, key, layout string, def time.Time) (time.Time, error) {
+	if FormValue(ctx, key) == "" {
+		return def, nil
+	}
+	return TimeParam(ctx, key, layout)
+}
+
+// EnumParam returns the parameter if it's one of the allowed values
+func EnumParam(ctx context.Context, key string, allowed ...string) (string, error) {
+	v, err := param(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	for i := range allowed {
+		if v == allowed[i] {
+			return v, nil
+		}
+	}
+	return "", paramError(key, v, fmt.Errorf("must be one of %s", strings.Join(allowed, ", ")))
+}
+
+// EnumParamOr returns the parameter if it's one of the allowed values, or def if it's not set
+func EnumParamOr(ctx context.Context, key, def string, allowed ...string) (string, error) {
+	if FormValue(ctx, key) == "" {
+		return def, nil
+	}
+	return EnumParam(ctx, key, allowed...)
+}
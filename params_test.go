@@ -0,0 +1,118 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func paramContext(query string) context.Context {
+	return Init(httptest.NewRecorder(), httptest.NewRequest("GET", "/?"+query, nil))
+}
+
+func assertParamError(t *testing.T, err error, name string) {
+	var paramErr *ParamError
+	if assert.True(t, errors.As(err, &paramErr)) {
+		assert.Equal(t, name, paramErr.Name)
+		assert.Equal(t, http.StatusBadRequest, GetErrorCode(context.Background(), err))
+		assert.Contains(t, err.Error(), name)
+	}
+}
+
+func TestIntParam(t *testing.T) {
+	ctx := paramContext("id=42&big=9000000000&bad=x")
+
+	n, err := IntParam(ctx, "id")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, n)
+
+	n64, err := Int64Param(ctx, "big")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9000000000), n64)
+
+	_, err = IntParam(ctx, "bad")
+	assertParamError(t, err, "bad")
+
+	_, err = IntParam(ctx, "missing")
+	assertParamError(t, err, "missing")
+	assert.True(t, errors.Is(err, ErrParamMissing))
+
+	n, err = IntParamOr(ctx, "missing", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, n)
+
+	_, err = IntParamOr(ctx, "bad", 10)
+	assertParamError(t, err, "bad")
+}
+
+func TestBoolParam(t *testing.T) {
+	ctx := paramContext("on=true&bad=maybe")
+
+	b, err := BoolParam(ctx, "on")
+	assert.NoError(t, err)
+	assert.True(t, b)
+
+	_, err = BoolParam(ctx, "bad")
+	assertParamError(t, err, "bad")
+
+	b, err = BoolParamOr(ctx, "off", true)
+	assert.NoError(t, err)
+	assert.True(t, b)
+}
+
+func TestUUIDParam(t *testing.T) {
+	ctx := paramContext("id=6ba7b810-9dad-11d1-80b4-00c04fd430c8&short=6ba7b8109dad11d180b400c04fd430c8&bad=xyz")
+
+	u, err := UUIDParam(ctx, "id")
+	assert.NoError(t, err)
+	assert.Equal(t, "6ba7b810-9dad-11d1-80b4-00c04fd430c8", u.String())
+
+	u2, err := UUIDParam(ctx, "short")
+	assert.NoError(t, err)
+	assert.Equal(t, u, u2)
+
+	_, err = UUIDParam(ctx, "bad")
+	assertParamError(t, err, "bad")
+	assert.True(t, errors.Is(err, ErrInvalidUUID))
+
+	u, err = UUIDParamOr(ctx, "missing", UUID{})
+	assert.NoError(t, err)
+	assert.Equal(t, UUID{}, u)
+}
+
+func TestTimeParam(t *testing.T) {
+	ctx := paramContext("day=2017-01-02&bad=yesterday")
+
+	d, err := TimeParam(ctx, "day", "2006-01-02")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2017, 1, 2, 0, 0, 0, 0, time.UTC), d)
+
+	_, err = TimeParam(ctx, "bad", "2006-01-02")
+	assertParamError(t, err, "bad")
+
+	def := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	d, err = TimeParamOr(ctx, "missing", "2006-01-02", def)
+	assert.NoError(t, err)
+	assert.Equal(t, def, d)
+}
+
+func TestEnumParam(t *testing.T) {
+	ctx := paramContext("sort=asc&bad=up")
+
+	s, err := EnumParam(ctx, "sort", "asc", "desc")
+	assert.NoError(t, err)
+	assert.Equal(t, "asc", s)
+
+	_, err = EnumParam(ctx, "bad", "asc", "desc")
+	assertParamError(t, err, "bad")
+	assert.Contains(t, err.Error(), "asc, desc")
+
+	s, err = EnumParamOr(ctx, "missing", "desc", "asc", "desc")
+	assert.NoError(t, err)
+	assert.Equal(t, "desc", s)
+}
@@ -0,0 +1,215 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// ProblemNamespace is the XML namespace for problem details defined by RFC 7807
+const ProblemNamespace = "urn:ietf:rfc:7807"
+
+// Problem is an error with the problem details defined by RFC 7807. The default OnError
+// renders it as application/problem+json, or application/problem+xml if the request
+// prefers XML. Extensions are added as extra members of the problem object.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// NewProblem returns a Problem with the title set to the status text of the code
+func NewProblem(code int, detail string) *Problem {
+	return &Problem{Title: http.StatusText(code), Status: code, Detail: detail}
+}
+
+// ProblemFrom converts err to a *Problem. If err is, or wraps, a *Problem it's returned as is,
+// and an *APIError is converted with its Problem() method. Any other error becomes a Problem
+// with the status code and its status text. Error messages are only used as the detail for
+// 4xx codes when the error is one of the package's client facing errors, like a *DecodeError
+// or *ParamError, so internal errors aren't exposed.
+func ProblemFrom(code int, err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Problem()
+	}
+
+	p = NewProblem(code, "")
+	var s StatusCode
+	if errors.As(err, &s) || code >= http.StatusInternalServerError {
+		return p
+	}
+	if clientErr := clientError(err); clientErr != nil {
+		p.Detail = clientErr.Error()
+	}
+	return p
+}
+
+// clientError returns the first error in the chain whose message is meant for the client
+func clientError(err error) error {
+	var (
+		decodeErr *DecodeError
+		bindErr   *BindError
+		paramErr  *ParamError
+		initErr   *InitError
+		uploadErr *UploadError
+	)
+	switch {
+	case errors.As(err, &decodeErr):
+		return decodeErr
+	case errors.As(err, &bindErr):
+		return bindErr
+	case errors.As(err, &paramErr):
+		return paramErr
+	case errors.As(err, &initErr):
+		return initErr
+	case errors.As(err, &uploadErr):
+		return uploadErr
+	}
+	return nil
+}
+
+// Problem returns a minimal Problem for the status code
+func (s StatusCode) Problem() *Problem {
+	return NewProblem(s.Code(), "")
+}
+
+// With returns the problem with the extension member set
+func (p *Problem) With(key string, val interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]interface{}{}
+	}
+	p.Extensions[key] = val
+	return p
+}
+
+func (p *Problem) Error() string {
+	title := p.Title
+	if title == "" {
+		title = http.StatusText(p.Code())
+	}
+	if p.Detail == "" {
+		return title
+	}
+	return fmt.Sprintf("%s: %s", title, p.Detail)
+}
+
+// Code returns the status of the problem, or 500 if it's not set
+func (p *Problem) Code() int {
+	if p.Status == 0 {
+		return http.StatusInternalServerError
+	}
+	return p.Status
+}
+
+// ContentType returns the application/problem+json content type
+func (p *Problem) ContentType() string {
+	return MIMEApplicationProblemJSON.String()
+}
+
+// members returns the standard members which are set, followed by the extensions
+func (p *Problem) members() map[string]interface{} {
+	m := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	for k, v := range map[string]string{"type": p.Type, "title": p.Title, "detail": p.Detail, "instance": p.Instance} {
+		if v != "" {
+			m[k] = v
+		}
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	return m
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.members())
+}
+
+// MarshalXML implements the xml.Marshaler interface, using the format from appendix A of RFC 7807
+func (p *Problem) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Local: "problem"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: ProblemNamespace}}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	m := p.members()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := e.EncodeElement(m[k], xml.StartElement{Name: xml.Name{Local: k}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// WriteProblem writes the problem to the response as application/problem+xml if the request
+// prefers XML, otherwise as application/problem+json.
+func WriteProblem(ctx context.Context, p *Problem) error {
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
+	addVary(w.Header(), HeaderAccept)
+	mt, _ := Negotiate(ctx, MIMEApplicationProblemJSON, MIMEApplicationJSON, MIMEApplicationProblemXML, MIMEApplicationXML, MIMETextXML)
+	return writeProblem(w, mt, p)
+}
+
+// problemPage is the page used for problems when the request prefers HTML
+var problemPage = template.Must(template.New("problem").Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.Code}} {{.Title}}</title></head>
+<body>
+<h1>{{.Title}}</h1>
+{{with .Detail}}<p>{{.}}</p>
+{{end}}</body>
+</html>
+`))
+
+// writeProblem writes the problem in the format for the MIME type, which is JSON unless the
+// type is XML, HTML or plain text
+func writeProblem(w http.ResponseWriter, mt MIME, p *Problem) error {
+	w.Header().Set(HeaderXContentTypeOptions, "nosniff")
+	switch mt {
+	case MIMEApplicationProblemXML, MIMEApplicationXML, MIMETextXML:
+		w.Header().Set(HeaderContentType, MIMEApplicationProblemXML.String())
+		w.WriteHeader(p.Code())
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		return encodeXML(w, p)
+	case MIMETextHTML:
+		w.Header().Set(HeaderContentType, MIMETextHTMLCharsetUTF8.String())
+		w.WriteHeader(p.Code())
+		return problemPage.Execute(w, p)
+	case MIMETextPlain:
+		w.Header().Set(HeaderContentType, MIMETextPlainCharsetUTF8.String())
+		w.WriteHeader(p.Code())
+		_, err := io.WriteString(w, p.Error()+"\n")
+		return err
+	default:
+		w.Header().Set(HeaderContentType, MIMEApplicationProblemJSON.String())
+		w.WriteHeader(p.Code())
+		return encodeJSON(w, p)
+	}
+}
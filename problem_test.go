@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serveError(accept string, err error) *httptest.ResponseRecorder {
+	h := Handler(func(ctx context.Context) error {
+		return err
+	})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/users/1", nil)
+	if accept != "" {
+		r.Header.Set(HeaderAccept, accept)
+	}
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestProblemJSON(t *testing.T) {
+	p := &Problem{
+		Type:     "https://example.com/probs/out-of-credit",
+		Title:    "You do not have enough credit.",
+		Status:   http.StatusForbidden,
+		Detail:   "Your current balance is 30, but that costs 50.",
+		Instance: "/account/12345/msgs/abc",
+	}
+	w := serveError("application/json", fmt.Errorf("charge: %w", p.With("balance", 30)))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, MIMEApplicationProblemJSON.String(), w.Header().Get(HeaderContentType))
+
+	var got map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got)) {
+		assert.Equal(t, p.Type, got["type"])
+		assert.Equal(t, p.Title, got["title"])
+		assert.Equal(t, float64(403), got["status"])
+		assert.Equal(t, p.Detail, got["detail"])
+		assert.Equal(t, p.Instance, got["instance"])
+		assert.Equal(t, float64(30), got["balance"])
+	}
+}
+
+func TestProblemXML(t *testing.T) {
+	w := serveError("application/xml", NewProblem(http.StatusConflict, "already exists").With("id", 1))
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, MIMEApplicationProblemXML.String(), w.Header().Get(HeaderContentType))
+	assert.Contains(t, w.Body.String(), `<problem xmlns="urn:ietf:rfc:7807"><detail>already exists</detail><id>1</id><status>409</status><title>Conflict</title></problem>`)
+}
+
+func TestProblemStatusCode(t *testing.T) {
+	w := serveError("", StatusNotFound)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, MIMEApplicationProblemJSON.String(), w.Header().Get(HeaderContentType))
+	assert.JSONEq(t, `{"status":404,"title":"Not Found"}`, w.Body.String())
+}
+
+func TestProblemFrom(t *testing.T) {
+	p := ProblemFrom(http.StatusBadRequest, errors.New("bad id"))
+	assert.Equal(t, "", p.Detail)
+	assert.Equal(t, "Bad Request", p.Title)
+
+	paramErr := &ParamError{Name: "id", Err: ErrParamMissing}
+	p = ProblemFrom(http.StatusBadRequest, fmt.Errorf("get user: %w", paramErr))
+	assert.Equal(t, paramErr.Error(), p.Detail)
+
+	p = ProblemFrom(http.StatusNotFound, fmt.Errorf("load user 42 from shard db-3: %w", StatusNotFound))
+	assert.Equal(t, "", p.Detail)
+	assert.Equal(t, "Not Found", p.Title)
+
+	_, err := os.Open("/srv/secret/config.yaml")
+	p = ProblemFrom(http.StatusNotFound, err)
+	assert.Equal(t, "", p.Detail)
+
+	p = ProblemFrom(http.StatusInternalServerError, errors.New("db password is hunter2"))
+	assert.Equal(t, "", p.Detail)
+	assert.Equal(t, http.StatusInternalServerError, p.Code())
+
+	w := serveError("", errors.New("db password is hunter2"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.False(t, strings.Contains(w.Body.String(), "hunter2"))
+}
@@ -0,0 +1,100 @@
+// Package protobuf adds protocol buffers support to rest. Importing it registers an encoder
+// and decoder for application/protobuf, so Render() and Decode() handle proto.Message values,
+// and a decoder for TestRequest.Decode().
+package protobuf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/bradberger/rest"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNotProtoMessage is returned when protobuf encoding a value which isn't a proto.Message
+var ErrNotProtoMessage = errors.New("value does not implement proto.Message")
+
+func init() {
+	rest.RegisterEncoder(rest.MIMEApplicationProtobuf, encoder{})
+	rest.RegisterDecoder(rest.MIMEApplicationProtobuf, decode)
+	rest.RegisterResponseDecoder(rest.MIMEApplicationProtobuf, Unmarshal)
+}
+
+// encoder only encodes proto.Message values, so Render() only offers protobuf for them
+type encoder struct{}
+
+func (encoder) ContentType() string {
+	return rest.MIMEApplicationProtobuf.String()
+}
+
+func (encoder) CanEncode(v interface{}) bool {
+	_, ok := v.(proto.Message)
+	return ok
+}
+
+func (encoder) Encode(w io.Writer, v interface{}) error {
+	return Encode(w, v)
+}
+
+// Encode writes the proto.Message v to w
+func Encode(w io.Writer, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// decode decodes the body if dst is a proto.Message, otherwise the endpoint doesn't support
+// protobuf and it returns a StatusUnsupportedMediaType error.
+func decode(ctx context.Context, dst interface{}) error {
+	m, ok := dst.(proto.Message)
+	if !ok {
+		return rest.StatusUnsupportedMediaType
+	}
+	b, err := rest.ReadBody(ctx)
+	if err != nil {
+		return err
+	}
+	if err := proto.Unmarshal(b, m); err != nil {
+		return &rest.DecodeError{Err: err}
+	}
+	return nil
+}
+
+// Unmarshal decodes the protocol buffers data into dst, which must be a proto.Message
+func Unmarshal(b []byte, dst interface{}) error {
+	m, ok := dst.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+	return proto.Unmarshal(b, m)
+}
+
+// Protobuf writes the protocol buffers encoded message to the HTTP connection
+func Protobuf(ctx context.Context, code int, m proto.Message) error {
+	w := rest.ResponseWriter(ctx)
+	if w == nil {
+		return rest.ErrNoResponseWriter
+	}
+	w.Header().Set(rest.HeaderContentType, rest.MIMEApplicationProtobuf.String())
+	w.WriteHeader(code)
+	return Encode(w, m)
+}
+
+// TestPostProtobuf bootstraps a POST request to the given urlStr with the protocol buffers encoded message as the request body
+func TestPostProtobuf(urlStr string, m proto.Message) (*rest.TestRequest, error) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, m); err != nil {
+		return nil, err
+	}
+	return rest.TestPost(urlStr, rest.MIMEApplicationProtobuf.String(), &buf)
+}
@@ -0,0 +1,63 @@
+package protobuf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bradberger/rest"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type testUser struct {
+	Name string `json:"name"`
+}
+
+func serveRender(accept string, data interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(rest.HeaderAccept, accept)
+	rest.Handler(func(ctx context.Context) error {
+		return rest.Render(ctx, http.StatusOK, data)
+	}).ServeHTTP(w, r)
+	return w
+}
+
+func TestDecodeAndWrite(t *testing.T) {
+	r, err := TestPostProtobuf("/", wrapperspb.String("foo"))
+	assert.NoError(t, err)
+
+	var in wrapperspb.StringValue
+	assert.NoError(t, rest.Decode(r.Context, &in))
+	assert.Equal(t, "foo", in.GetValue())
+
+	var notProto testUser
+	assert.Equal(t, rest.StatusUnsupportedMediaType, rest.Decode(r.Context, &notProto))
+
+	assert.NoError(t, r.Do(func(ctx context.Context) error {
+		return Protobuf(ctx, http.StatusOK, wrapperspb.String("bar"))
+	}))
+	var out wrapperspb.StringValue
+	assert.NoError(t, r.Decode(&out))
+	assert.Equal(t, ErrNotProtoMessage, r.Decode(&notProto))
+	assert.Equal(t, rest.MIMEApplicationProtobuf.String(), r.Writer.Header().Get(rest.HeaderContentType))
+	assert.Equal(t, "bar", out.GetValue())
+}
+
+func TestRender(t *testing.T) {
+	w := serveRender(rest.MIMEApplicationProtobuf.String(), wrapperspb.Int64(42))
+	assert.Equal(t, rest.MIMEApplicationProtobuf.String(), w.Header().Get(rest.HeaderContentType))
+	var got wrapperspb.Int64Value
+	assert.NoError(t, proto.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, int64(42), got.GetValue())
+
+	// Values which aren't proto.Message fall back to other accepted types
+	w = serveRender("application/protobuf, application/json;q=0.5", &testUser{Name: "foo"})
+	assert.Equal(t, rest.MIMEApplicationJSONCharsetUTF8.String(), w.Header().Get(rest.HeaderContentType))
+
+	w = serveRender("application/protobuf", &testUser{Name: "foo"})
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
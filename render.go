@@ -0,0 +1,306 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Encoder encodes values written to the response by Render()
+type Encoder interface {
+	ContentType() string
+	Encode(w io.Writer, v interface{}) error
+}
+
+type encoderFunc struct {
+	contentType string
+	fn          func(w io.Writer, v interface{}) error
+}
+
+func (e encoderFunc) ContentType() string {
+	return e.contentType
+}
+
+func (e encoderFunc) Encode(w io.Writer, v interface{}) error {
+	return e.fn(w, v)
+}
+
+// ConditionalEncoder is an Encoder which can only encode some values, like protobuf which
+// requires a proto.Message. Render() skips it when negotiating if CanEncode() returns false.
+type ConditionalEncoder interface {
+	Encoder
+	CanEncode(v interface{}) bool
+}
+
+// xmlEncoder is a ConditionalEncoder for XML which rejects maps, which encoding/xml can't
+// encode, so that negotiation falls through to another encoder.
+type xmlEncoder struct {
+	encoderFunc
+}
+
+func (xmlEncoder) CanEncode(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == nil || t.Kind() != reflect.Map
+}
+
+// NewEncoder returns an Encoder which writes the given content-type header and encodes values with fn
+func NewEncoder(contentType string, fn func(w io.Writer, v interface{}) error) Encoder {
+	return encoderFunc{contentType: contentType, fn: fn}
+}
+
+// ErrNoEncoder is returned when a value's ResponseContentType has no registered encoder
+var ErrNoEncoder = errors.New("no encoder registered")
+
+// DefaultMIME is the type used by Render() when the request doesn't have an Accept header,
+// or accepts any type. App.DefaultMIME overrides it.
+var DefaultMIME = MIMEApplicationJSON
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[MIME]Encoder{
+		MIMEApplicationJSON: NewEncoder(MIMEApplicationJSONCharsetUTF8.String(), encodeJSON),
+		MIMEApplicationXML:  xmlEncoder{encoderFunc{MIMEApplicationXMLCharsetUTF8.String(), encodeXML}},
+		MIMETextXML:         xmlEncoder{encoderFunc{MIMETextXMLCharsetUTF8.String(), encodeXML}},
+	}
+	// encoderOrder is the order encoders were registered in, which breaks ties when
+	// the request accepts several types equally.
+	encoderOrder = []MIME{MIMEApplicationJSON, MIMEApplicationXML, MIMETextXML}
+)
+
+// RegisterEncoder registers the encoder used by Render() for the given type
+func RegisterEncoder(m MIME, e Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	if _, ok := encoders[m]; !ok {
+		encoderOrder = append(encoderOrder, m)
+	}
+	encoders[m] = e
+}
+
+func encodeJSON(w io.Writer, data interface{}) error {
+	switch data.(type) {
+	case io.Reader, *string, string, *[]byte, []byte:
+		return write(w, data)
+	default:
+		return json.NewEncoder(w).Encode(data)
+	}
+}
+
+func encodeXML(w io.Writer, data interface{}) error {
+	switch data.(type) {
+	case io.Reader, *string, string, *[]byte, []byte:
+		return write(w, data)
+	default:
+		return xml.NewEncoder(w).Encode(data)
+	}
+}
+
+// Render writes data to the response with the encoder which best matches the Accept header
+// of the request. If no encoder matches it returns a StatusNotAcceptable error without
+// writing anything. The data is encoded before anything is written, so an encoding error is
+// returned with the response left untouched.
+func Render(ctx context.Context, code int, data interface{}) error {
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
+
+	addVary(w.Header(), HeaderAccept)
+	m, ok := negotiateEncoder(ctx, data)
+	if !ok {
+		return StatusNotAcceptable
+	}
+
+	encodersMu.RLock()
+	e := encoders[m]
+	encodersMu.RUnlock()
+
+	return encode(w, code, e.ContentType(), e, data)
+}
+
+// encode encodes data into a buffer, and only writes the headers and body if that succeeds
+func encode(w http.ResponseWriter, code int, contentType string, e Encoder, data interface{}) error {
+	var buf bytes.Buffer
+	if err := e.Encode(&buf, data); err != nil {
+		return err
+	}
+	w.Header().Set(HeaderContentType, contentType)
+	w.WriteHeader(code)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// renderContentType writes data with the encoder registered for the content-type, instead of
+// negotiating one. The content-type header is set as given. If no encoder is registered for
+// it, an ErrNoEncoder error is returned without writing anything, which is a server error
+// rather than a mismatch with the Accept header.
+func renderContentType(ctx context.Context, code int, contentType string, data interface{}) error {
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
+	e, ok := getEncoder(MIME(mt))
+	if !ok {
+		return fmt.Errorf("%w for %s", ErrNoEncoder, mt)
+	}
+
+	return encode(w, code, contentType, e, data)
+}
+
+// getEncoder returns the encoder registered for the type, using the JSON or XML encoders for
+// types with a +json or +xml suffix
+func getEncoder(m MIME) (Encoder, bool) {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+
+	if e, ok := encoders[m]; ok {
+		return e, true
+	}
+	switch {
+	case strings.HasSuffix(string(m), "+json"):
+		e, ok := encoders[MIMEApplicationJSON]
+		return e, ok
+	case strings.HasSuffix(string(m), "+xml"):
+		e, ok := encoders[MIMEApplicationXML]
+		return e, ok
+	}
+	return nil, false
+}
+
+func negotiateEncoder(ctx context.Context, data interface{}) (MIME, bool) {
+	encodersMu.RLock()
+	offers := make([]MIME, 0, len(encoderOrder)+1)
+	for _, m := range append([]MIME{AppFromContext(ctx).defaultMIME()}, encoderOrder...) {
+		e, ok := encoders[m]
+		if !ok {
+			continue
+		}
+		if c, ok := e.(ConditionalEncoder); ok && !c.CanEncode(data) {
+			continue
+		}
+		offers = append(offers, m)
+	}
+	encodersMu.RUnlock()
+
+	return Negotiate(ctx, offers...)
+}
+
+// Negotiate returns the type from offers which best matches the Accept header of the request,
+// preferring earlier offers when the request accepts several equally. If the request has no
+// Accept header the first offer is returned. It returns false if none of the offers match.
+func Negotiate(ctx context.Context, offers ...MIME) (MIME, bool) {
+	if len(offers) == 0 {
+		return "", false
+	}
+	accept := Headers(ctx).Get(HeaderAccept)
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	ranges := parseAccept(accept)
+	var best MIME
+	bestQ := 0.0
+	for _, offer := range offers {
+		if q := acceptQuality(ranges, offer); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+type acceptRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// specificity returns how specific the range is, so that "text/html" takes precedence
+// over "text/*", which takes precedence over "*/*".
+func (a acceptRange) specificity() int {
+	switch {
+	case a.typ == "*":
+		return 0
+	case a.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (a acceptRange) matches(typ, subtype string) bool {
+	return (a.typ == "*" || a.typ == typ) && (a.subtype == "*" || a.subtype == subtype)
+}
+
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mt := strings.ToLower(strings.TrimSpace(params[0]))
+		if mt == "*" {
+			mt = "*/*"
+		}
+		slash := strings.IndexByte(mt, '/')
+		if slash < 0 {
+			continue
+		}
+
+		r := acceptRange{typ: mt[:slash], subtype: mt[slash+1:], q: 1}
+		for _, p := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+			if len(kv) == 2 && strings.ToLower(kv[0]) == "q" {
+				if q, err := strconv.ParseFloat(kv[1], 64); err == nil {
+					r.q = q
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+// acceptQuality returns the quality of the most specific range matching m
+func acceptQuality(ranges []acceptRange, m MIME) float64 {
+	mt := strings.ToLower(strings.TrimSpace(strings.Split(string(m), ";")[0]))
+	slash := strings.IndexByte(mt, '/')
+	if slash < 0 {
+		return 0
+	}
+	for _, r := range ranges {
+		if r.matches(mt[:slash], mt[slash+1:]) {
+			return r.q
+		}
+	}
+	return 0
+}
+
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values(HeaderVary) {
+		for _, f := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(f), value) {
+				return
+			}
+		}
+	}
+	h.Add(HeaderVary, value)
+}
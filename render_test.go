@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serveRender(accept string, data interface{}) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	if accept != "" {
+		r.Header.Set(HeaderAccept, accept)
+	}
+	Handler(func(ctx context.Context) error {
+		return Render(ctx, http.StatusOK, data)
+	}).ServeHTTP(w, r)
+	return w
+}
+
+func TestRender(t *testing.T) {
+	data := &testResult{Name: "foo"}
+
+	for _, accept := range []string{"", "*/*", "application/json", "text/html;q=0.9, application/*;q=0.8"} {
+		w := serveRender(accept, data)
+		assert.Equal(t, http.StatusOK, w.Code, accept)
+		assert.Equal(t, MIMEApplicationJSONCharsetUTF8.String(), w.Header().Get(HeaderContentType), accept)
+		assert.Equal(t, `{"name":"foo"}`, strings.TrimSpace(w.Body.String()), accept)
+		assert.Equal(t, HeaderAccept, w.Header().Get(HeaderVary), accept)
+	}
+
+	w := serveRender("application/json;q=0.5, application/xml", data)
+	assert.Equal(t, MIMEApplicationXMLCharsetUTF8.String(), w.Header().Get(HeaderContentType))
+	assert.Equal(t, "<result><name>foo</name></result>", w.Body.String())
+
+	w = serveRender("text/*, application/json;q=0.1", data)
+	assert.Equal(t, MIMETextXMLCharsetUTF8.String(), w.Header().Get(HeaderContentType))
+
+	w = serveRender("image/png, application/json;q=0", data)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+}
+
+func TestRenderDefaultMIME(t *testing.T) {
+	defer func() { DefaultMIME = MIMEApplicationJSON }()
+	DefaultMIME = MIMEApplicationXML
+
+	w := serveRender("", &testResult{Name: "foo"})
+	assert.Equal(t, MIMEApplicationXMLCharsetUTF8.String(), w.Header().Get(HeaderContentType))
+	w = serveRender("*/*", &testResult{Name: "foo"})
+	assert.Equal(t, MIMEApplicationXMLCharsetUTF8.String(), w.Header().Get(HeaderContentType))
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	m := MIME("text/csv")
+	RegisterEncoder(m, NewEncoder("text/csv", func(w io.Writer, v interface{}) error {
+		cw := csv.NewWriter(w)
+		cw.Write([]string{fmt.Sprint(v)})
+		cw.Flush()
+		return cw.Error()
+	}))
+	defer func() {
+		encodersMu.Lock()
+		delete(encoders, m)
+		encoderOrder = encoderOrder[:len(encoderOrder)-1]
+		encodersMu.Unlock()
+	}()
+
+	w := serveRender("text/csv", "foo")
+	assert.Equal(t, "text/csv", w.Header().Get(HeaderContentType))
+	assert.Equal(t, "foo\n", w.Body.String())
+}
+
+func TestRenderEncodeError(t *testing.T) {
+	for _, accept := range []string{"*/*", "application/xml, application/json;q=0.1", "text/*, application/json;q=0.5"} {
+		w := serveRender(accept, map[string]int{"a": 1})
+		assert.Equal(t, http.StatusOK, w.Code, accept)
+		assert.Equal(t, MIMEApplicationJSONCharsetUTF8.String(), w.Header().Get(HeaderContentType), accept)
+		assert.Equal(t, `{"a":1}`, strings.TrimSpace(w.Body.String()), accept)
+	}
+
+	// Maps can't be encoded as XML, so they aren't acceptable if JSON isn't
+	for _, accept := range []string{"application/xml", "text/*", "application/json;q=0, */*"} {
+		w := serveRender(accept, map[string]int{"a": 1})
+		assert.Equal(t, http.StatusNotAcceptable, w.Code, accept)
+	}
+
+	// Encoding errors are returned before anything is written
+	w := serveRender("application/xml", &struct{ C chan int }{})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, MIMEApplicationProblemXML.String(), w.Header().Get(HeaderContentType))
+}
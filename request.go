@@ -2,15 +2,16 @@ package rest
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"io"
+	"mime"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
-
-	"github.com/bradberger/context"
+	"sync"
 )
 
 var (
@@ -18,6 +19,22 @@ var (
 	ErrUnknownContentType = errors.New("could not determinte content type")
 )
 
+// ResponseDecoder decodes the body of a test response into dst
+type ResponseDecoder func(b []byte, dst interface{}) error
+
+var (
+	responseDecodersMu sync.RWMutex
+	responseDecoders   = map[MIME]ResponseDecoder{}
+)
+
+// RegisterResponseDecoder registers the decoder used by TestRequest.Decode() for the given
+// content-type, in addition to JSON and XML
+func RegisterResponseDecoder(m MIME, d ResponseDecoder) {
+	responseDecodersMu.Lock()
+	defer responseDecodersMu.Unlock()
+	responseDecoders[m] = d
+}
+
 type TestRequest struct {
 	Writer  *httptest.ResponseRecorder
 	Request *http.Request
@@ -35,7 +52,14 @@ func (r *TestRequest) Decode(data interface{}) error {
 	case "application/xml":
 		return xml.NewDecoder(r.Writer.Body).Decode(data)
 	default:
-		return ErrUnknownContentType
+		mt, _, _ := mime.ParseMediaType(r.Request.Header.Get("Content-Type"))
+		responseDecodersMu.RLock()
+		d, ok := responseDecoders[MIME(mt)]
+		responseDecodersMu.RUnlock()
+		if !ok {
+			return ErrUnknownContentType
+		}
+		return d(r.Writer.Body.Bytes(), data)
 	}
 }
 
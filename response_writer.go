@@ -0,0 +1,139 @@
+package rest
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+var (
+	_ http.ResponseWriter = (*responseWriter)(nil)
+	_ http.Flusher        = (*responseWriter)(nil)
+	_ http.Hijacker       = (*responseWriter)(nil)
+)
+
+// responseWriter wraps the http.ResponseWriter to keep track of the status code, the
+// number of bytes written and when the headers were written.
+type responseWriter struct {
+	http.ResponseWriter
+	code        int
+	pendingCode int
+	size        int64
+	wroteHeader bool
+	start       time.Time
+	firstByte   time.Duration
+}
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w, start: time.Now()}
+}
+
+// WriteHeader implements the http.ResponseWriter interface. Only the first call
+// is passed on to the underlying writer.
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.code = code
+	w.firstByte = time.Since(w.start)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements the http.ResponseWriter interface. If the headers haven't been
+// written yet, they're written with the code set with SetCode() or 200.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		code := w.pendingCode
+		if code == 0 {
+			code = http.StatusOK
+		}
+		w.WriteHeader(code)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Flush implements the http.Flusher interface
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if !w.wroteHeader {
+			w.Write(nil)
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements the http.Hijacker interface
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use with http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func getResponseWriter(ctx context.Context) *responseWriter {
+	w, _ := Value[*responseWriter](ctx, ContextKeyResponseWriter)
+	return w
+}
+
+// SetCode sets the http response code for the request. It's used when the body is written
+// without an explicit call to WriteHeader(), and by GetErrorCode() if an error is returned.
+// It has no effect once the headers have been written.
+func SetCode(ctx context.Context, code int) {
+	if w := getResponseWriter(ctx); w != nil {
+		w.pendingCode = code
+	}
+}
+
+// GetCode returns the http response code associated with the request. That's the code
+// which was written if the headers have been written, otherwise the code set with
+// SetCode(), or 200 if neither happened.
+func GetCode(ctx context.Context) int {
+	w := getResponseWriter(ctx)
+	switch {
+	case w == nil:
+		return http.StatusOK
+	case w.wroteHeader:
+		return w.code
+	case w.pendingCode != 0:
+		return w.pendingCode
+	default:
+		return http.StatusOK
+	}
+}
+
+// Written returns true if the response headers have been written
+func Written(ctx context.Context) bool {
+	if w := getResponseWriter(ctx); w != nil {
+		return w.wroteHeader
+	}
+	return false
+}
+
+// BytesWritten returns the number of bytes of the response body written so far
+func BytesWritten(ctx context.Context) int64 {
+	if w := getResponseWriter(ctx); w != nil {
+		return w.size
+	}
+	return 0
+}
+
+// TimeToFirstByte returns the time between the start of the request and writing the
+// response headers, or zero if they haven't been written yet.
+func TimeToFirstByte(ctx context.Context) time.Duration {
+	if w := getResponseWriter(ctx); w != nil {
+		return w.firstByte
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseWriter(t *testing.T) {
+	var code, written int
+	var headerWritten bool
+	h := Handler(func(ctx context.Context) error {
+		assert.False(t, Written(ctx))
+		assert.Equal(t, http.StatusOK, GetCode(ctx))
+		SetCode(ctx, http.StatusCreated)
+		assert.Equal(t, http.StatusCreated, GetCode(ctx))
+		_, err := ResponseWriter(ctx).Write([]byte("created"))
+		code, written, headerWritten = GetCode(ctx), int(BytesWritten(ctx)), Written(ctx)
+		return err
+	})
+
+	w := serve(h, "GET", "/")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, 7, written)
+	assert.True(t, headerWritten)
+}
+
+func TestResponseWriterWriteHeaderOnce(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := Init(w, httptest.NewRequest("GET", "/", nil))
+	assert.NoError(t, Text(ctx, http.StatusAccepted, "ok"))
+	ResponseWriter(ctx).WriteHeader(http.StatusInternalServerError)
+	assert.Equal(t, http.StatusAccepted, GetCode(ctx))
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.True(t, TimeToFirstByte(ctx) > 0)
+}
+
+func TestSetCodeHonoredOnError(t *testing.T) {
+	h := Handler(func(ctx context.Context) error {
+		SetCode(ctx, http.StatusConflict)
+		return StatusInternalServerError
+	})
+	assert.Equal(t, http.StatusConflict, serve(h, "GET", "/").Code)
+}
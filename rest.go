@@ -2,60 +2,131 @@
 package rest
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
-	"io/ioutil"
+	"fmt"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"runtime/debug"
 
-	"github.com/bradberger/context"
 	"github.com/gorilla/mux"
 )
 
 // Context key standardized definitions
 var (
-	ContextKeyNamepace       context.Key = "namespace"
-	ContextKeyRequestBody    context.Key = "request.body"
-	ContextKeyRequestVars    context.Key = "request.vars"
-	ContextKeyResponseWriter context.Key = "http.responsewriter"
-	ContextKeyRequest        context.Key = "request"
-	ContextKeyResponseCode   context.Key = "response.code"
-	ContextKeyInitialized    context.Key = "initialized"
-	ContextKeyResponseBody   context.Key = "response.body"
-	ContextKeyEnvironment    context.Key = "environment"
+	ContextKeyNamepace       = &contextKey{"namespace"}
+	ContextKeyRequestBody    = &contextKey{"request.body"}
+	ContextKeyRequestVars    = &contextKey{"request.vars"}
+	ContextKeyResponseWriter = &contextKey{"http.responsewriter"}
+	ContextKeyRequest        = &contextKey{"request"}
+	ContextKeyResponseCode   = &contextKey{"response.code"}
+	ContextKeyInitialized    = &contextKey{"initialized"}
+	ContextKeyResponseBody   = &contextKey{"response.body"}
+	ContextKeyEnvironment    = &contextKey{"environment"}
+	ContextKeyPanic          = &contextKey{"panic"}
+	ContextKeyPanicStack     = &contextKey{"panic.stack"}
+
+	contextKeyDeferred = &contextKey{"deferred"}
+	contextKeyChain    = &contextKey{"chain"}
+	contextKeyStrict   = &contextKey{"strict"}
+	contextKeyTimeout  = &contextKey{"timeout"}
+)
+
+// Errors returned by the context accessors
+var (
+	ErrNoRequest        = errors.New("no request in context")
+	ErrNoResponseWriter = errors.New("no response writer in context")
 )
 
 // AppHandler is the wrapper for all HTTP requests. It provides a valid context, authorization information, and route parameters.
-// The returned interface is written to the response, unless an error is returned.
+// If an error is returned, it's passed on to OnError and the rest of the chain is skipped. Use a ResultHandler
+// to have a returned value written to the response.
 type AppHandler func(ctx context.Context) error
 
-// Handler is a chainable set of AppHandler middleware funcs
+// PanicError is the error passed to OnError when an AppHandler panics and no OnPanic handler is defined
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+// Handler is a chainable set of AppHandler middleware funcs. Panics in any of the funcs are
+// recovered and passed to OnPanic, or to OnError with a 500 if OnPanic is not defined. Funcs
+// registered with Defer() are run once the response has been written. It uses the hooks of
+// DefaultApp.
 func Handler(fn ...AppHandler) http.Handler {
+	return DefaultApp.Handler(fn...)
+}
+
+func (a *App) handler(after []AfterFunc, fn []AppHandler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := Init(w, r)
-		for i := range fn {
-			if err := fn[i](ctx); err != nil {
-				OnError(ctx, GetErrorCode(ctx, err), err)
+		ctx, err := a.initContext(w, r)
+		for i := range after {
+			Defer(ctx, after[i])
+		}
+		defer func() {
+			v := recover()
+			if v == nil {
+				runDeferred(ctx, err)
 				return
 			}
+			// http.ErrAbortHandler is used to abort a response on purpose, so let net/http deal with it.
+			if v == http.ErrAbortHandler {
+				runDeferred(ctx, &PanicError{Value: v})
+				panic(v)
+			}
+			runDeferred(ctx, a.recoverPanic(ctx, v, debug.Stack()))
+		}()
+
+		if err == nil {
+			err = runChain(ctx, fn)
+		}
+		if h, ok := err.(handledError); ok {
+			err = h.error
+		} else if err != nil {
+			a.handleError(ctx, err)
 		}
 	})
 }
 
-// Decode decodes the http request body in JSON format into the dst variable.
-func Decode(ctx context.Context, dst interface{}) error {
-	b := ctx.Value(ContextKeyRequestBody)
-	if b == nil {
-		return errors.New("no request body")
+func (a *App) recoverPanic(ctx context.Context, v interface{}, stack []byte) error {
+	a.criticalf()(ctx, "panic: %v\n%s", v, stack)
+	ctx = setValue(ctx, ContextKeyPanic, v)
+	ctx = setValue(ctx, ContextKeyPanicStack, stack)
+
+	perr := &PanicError{Value: v, Stack: stack}
+	onPanic := a.onPanic()
+	if onPanic == nil {
+		a.onError()(ctx, http.StatusInternalServerError, perr)
+		return perr
+	}
+	if err := onPanic(ctx); err != nil {
+		a.handleError(ctx, err)
 	}
-	return json.Unmarshal(b.([]byte), dst)
+	return perr
+}
+
+// Recovered returns the value recovered from a panic. It is only set in the context passed to OnPanic
+// and OnError after a panic, otherwise it's nil.
+func Recovered(ctx context.Context) interface{} {
+	return ctx.Value(ContextKeyPanic)
+}
+
+// Stack returns the stack trace captured when a panic was recovered, or nil if there was no panic
+func Stack(ctx context.Context) []byte {
+	v, _ := Value[[]byte](ctx, ContextKeyPanicStack)
+	return v
 }
 
-// Body returns the body of the request as a byte slice
+// Body returns the body of the request as a byte slice. If the body can't be read
+// it returns an empty slice, use ReadBody() to get the error.
 func Body(ctx context.Context) []byte {
-	b := ctx.Value(ContextKeyRequestBody).([]byte)
-	if b == nil {
+	b, err := ReadBody(ctx)
+	if err != nil || b == nil {
 		return []byte{}
 	}
 	return b
@@ -66,46 +137,141 @@ func BodyString(ctx context.Context) string {
 	return string(Body(ctx))
 }
 
-// FormValue returns the form value (or mux.Vars value) from the request
+// FormValue returns the first value for the key from the request. If the key is set in more
+// than one place, mux route variables take precedence over the url encoded body form, which
+// takes precedence over the query string. The body form is parsed on first use, so it's
+// subject to the limits set with LimitBody(). Multipart bodies aren't parsed, so that they
+// can still be streamed with Upload().
 func FormValue(ctx context.Context, key string) string {
-	v := ctx.Value(ContextKeyRequestVars)
-	if v == nil {
-		return ""
+	if v := FormValues(ctx, key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// FormValues returns all the values for the key from the request. It uses the same precedence
+// as FormValue(), returning the values from the route variables, body form or query string,
+// whichever is the first to have the key. It returns nil if the key isn't set.
+func FormValues(ctx context.Context, key string) []string {
+	if v, ok := pathValue(ctx, key); ok {
+		return []string{v}
+	}
+	if v := PostFormValues(ctx, key); len(v) > 0 {
+		return v
+	}
+	return QueryValues(ctx, key)
+}
+
+// PathValue returns the mux route variable for the key
+func PathValue(ctx context.Context, key string) string {
+	v, _ := pathValue(ctx, key)
+	return v
+}
+
+func pathValue(ctx context.Context, key string) (string, bool) {
+	r := Request(ctx)
+	if r == nil {
+		return "", false
+	}
+	v, ok := mux.Vars(r)[key]
+	return v, ok
+}
+
+// QueryValue returns the first value for the key from the URL query string
+func QueryValue(ctx context.Context, key string) string {
+	if v := QueryValues(ctx, key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// QueryValues returns all the values for the key from the URL query string
+func QueryValues(ctx context.Context, key string) []string {
+	r := Request(ctx)
+	if r == nil {
+		return nil
+	}
+	return r.URL.Query()[key]
+}
+
+// PostFormValue returns the first value for the key from the url encoded form body
+func PostFormValue(ctx context.Context, key string) string {
+	if v := PostFormValues(ctx, key); len(v) > 0 {
+		return v[0]
 	}
-	return v.(map[string]string)[key]
+	return ""
 }
 
-// ResponseWriter returns the response writer for the given context
+// PostFormValues returns all the values for the key from the url encoded form body. Like
+// FormValue(), it doesn't parse multipart bodies. If the form can't be parsed, for example
+// because the body is too large, it returns nil.
+func PostFormValues(ctx context.Context, key string) []string {
+	r, err := parseForm(ctx)
+	if err != nil {
+		return nil
+	}
+	return r.PostForm[key]
+}
+
+// parseForm parses the url encoded form body of the request, if it hasn't been already. It's
+// done on first use rather than by Init(), so that limits set by handlers apply to it.
+func parseForm(ctx context.Context) (*http.Request, error) {
+	r := Request(ctx)
+	if r == nil {
+		return nil, ErrNoRequest
+	}
+	b := getBody(ctx)
+	if b == nil {
+		b = &requestBody{}
+	}
+	return r, b.parseForm(r)
+}
+
+// ResponseWriter returns the response writer for the given context, or nil if there isn't one
 func ResponseWriter(ctx context.Context) http.ResponseWriter {
-	return ctx.Value(ContextKeyResponseWriter).(http.ResponseWriter)
+	w, _ := Value[http.ResponseWriter](ctx, ContextKeyResponseWriter)
+	return w
 }
 
-// Request returns the http.Request asso
+// Request returns the http.Request associated with the context, or nil if there isn't one
 func Request(ctx context.Context) *http.Request {
-	return ctx.Value(ContextKeyRequest).(*http.Request)
+	r, _ := Value[*http.Request](ctx, ContextKeyRequest)
+	return r
 }
 
-// Header returns the ResponseWriter headers
+// Header returns the ResponseWriter headers. If there's no response writer, an empty header is returned.
 func Header(ctx context.Context) http.Header {
-	return ResponseWriter(ctx).Header()
+	w := ResponseWriter(ctx)
+	if w == nil {
+		return http.Header{}
+	}
+	return w.Header()
 }
 
-// Headers returns the http.Request headers
+// Headers returns the http.Request headers. If there's no request, an empty header is returned.
 func Headers(ctx context.Context) http.Header {
-	return Request(ctx).Header
+	r := Request(ctx)
+	if r == nil {
+		return http.Header{}
+	}
+	return r.Header
 }
 
-// GetCode returns the http response code associated with the request
-func GetCode(ctx context.Context) int {
-	if v := ctx.Value(context.ContextKeyResponseCode); v != nil {
-		return v.(int)
+func writer(ctx context.Context) (http.ResponseWriter, error) {
+	if w := ResponseWriter(ctx); w != nil {
+		return w, nil
 	}
-	return http.StatusOK
+	return nil, ErrNoResponseWriter
 }
 
-// FormFile matches the "net/http".Request.FormFile api
+// FormFile matches the "net/http".Request.FormFile api. It parses the whole multipart body,
+// so use Upload() to stream large files instead.
 func FormFile(ctx context.Context, key string) (multipart.File, *multipart.FileHeader, error) {
-	return Request(ctx).FormFile(key)
+	r := Request(ctx)
+	if r == nil {
+		return nil, nil, ErrNoRequest
+	}
+	return r.FormFile(key)
 }
 
 func setRequest(ctx context.Context, r *http.Request) context.Context {
@@ -116,18 +282,20 @@ func setValue(ctx context.Context, key, val interface{}) context.Context {
 	return context.WithValue(ctx, key, val)
 }
 
+// setVars stores the query string values and mux route variables. The body form isn't parsed
+// here, so that it's read after any LimitBody() handlers have run.
 func setVars(ctx context.Context) (context.Context, error) {
 
 	v := map[string]string{}
 	r := Request(ctx)
 
-	err := r.ParseForm()
+	query, err := url.ParseQuery(r.URL.RawQuery)
 	if err != nil {
-		return ctx, err
+		return ctx, newInitError("form", http.StatusBadRequest, err)
 	}
 
-	for i := range r.Form {
-		v[i] = r.FormValue(i)
+	for i := range query {
+		v[i] = query.Get(i)
 	}
 	for i := range mux.Vars(r) {
 		v[i] = mux.Vars(r)[i]
@@ -137,36 +305,110 @@ func setVars(ctx context.Context) (context.Context, error) {
 	return ctx, nil
 }
 
-func setBody(ctx context.Context) (context.Context, error) {
-	r := Request(ctx)
-	bodyBytes, err := ioutil.ReadAll(r.Body)
-	r.Body.Close()
-	// Reset the body so it can be read again.
-	r.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
-	if err != nil {
-		return ctx, err
+func setWriter(ctx context.Context, w http.ResponseWriter) context.Context {
+	return setValue(ctx, ContextKeyResponseWriter, w)
+}
+
+// InitError is returned when the request context can't be initialized, for example
+// when the form can't be parsed or the body is too large. Op is the initialization step
+// which failed, one of "namespace", "form" or "body". Since the body is read lazily, "body"
+// errors are returned from ReadBody(), Decode() and reads of BodyReader(), and "form" errors
+// for the body form from Decode() and Bind().
+type InitError struct {
+	Op     string
+	Status int
+	Err    error
+}
+
+func newInitError(op string, code int, err error) *InitError {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		code = http.StatusRequestEntityTooLarge
 	}
-	return setValue(ctx, ContextKeyRequestBody, bodyBytes), nil
+	return &InitError{Op: op, Status: code, Err: err}
 }
 
-func setWriter(ctx context.Context, w http.ResponseWriter) context.Context {
-	return setValue(ctx, ContextKeyResponseWriter, w)
+func (e *InitError) Error() string {
+	return fmt.Sprintf("init %s: %v", e.Op, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *InitError) Unwrap() error {
+	return e.Err
 }
 
-// Init returns a context with the reader, writer, and other context variables set
+// Code returns the http status code for the error, which implements the ResponseCode interface
+func (e *InitError) Code() int {
+	return e.Status
+}
+
+// Init returns a context with the reader, writer, and other context variables set. The context
+// is derived from r.Context(), so it's canceled when the client disconnects, and the request
+// returned by Request() carries the context values too. Any initialization errors are ignored,
+// use Handler() to have them passed on to OnError. It uses the hooks of DefaultApp.
 func Init(w http.ResponseWriter, r *http.Request) context.Context {
+	return DefaultApp.Init(w, r)
+}
+
+func (a *App) initContext(w http.ResponseWriter, r *http.Request) (ctx context.Context, err error) {
+
+	// If the request comes from another Handler, reuse its context so the body isn't read again.
+	if initialized, _ := Value[bool](r.Context(), ContextKeyInitialized); initialized {
+		ctx = setValue(r.Context(), contextKeyDeferred, &deferred{})
+		ctx = a.setApp(ctx)
+		return withRequest(ctx, w, r)
+	}
 
-	ctx := context.NewContext(r)
+	ctx = newContext(r)
 	ctx = setRequest(ctx, r)
-	ctx = setWriter(ctx, w)
+	ctx = setWriter(ctx, newResponseWriter(w))
+	ctx = setValue(ctx, contextKeyDeferred, &deferred{})
+	ctx = a.setApp(ctx)
 
-	// TODO Figure out how to handle errors here
-	ctx, _ = setNamespace(ctx)
-	ctx, _ = setVars(ctx)
-	ctx, _ = setBody(ctx)
+	nsCtx, err := setNamespace(ctx, a.namespace())
+	if err != nil {
+		return ctx, newInitError("namespace", http.StatusInternalServerError, err)
+	}
+	if ctx, err = setVars(nsCtx); err != nil {
+		return
+	}
+
+	// Attach the values to the request too, so that Request(ctx).Context() returns ctx itself.
+	req := new(http.Request)
+	ctx = setRequest(ctx, req)
+	ctx = setBody(ctx)
+	ctx = setValue(ctx, ContextKeyInitialized, true)
+	*req = *r.WithContext(ctx)
+	return ctx, nil
+}
+
+// setApp stores the app, and its environment if it's set, in the context
+func (a *App) setApp(ctx context.Context) context.Context {
+	ctx = setValue(ctx, contextKeyApp, a)
+	if a.Environment != "" {
+		ctx = WithEnvironment(ctx, a.Environment)
+	}
 	return ctx
 }
 
+// withRequest updates an initialized context for a request and response writer which have been
+// replaced, for example by http middleware.
+func withRequest(ctx context.Context, w http.ResponseWriter, r *http.Request) (context.Context, error) {
+	if w != ResponseWriter(ctx) {
+		ctx = setWriter(ctx, newResponseWriter(w))
+	}
+
+	ctx, err := setVars(setRequest(ctx, r))
+	if err != nil {
+		return ctx, err
+	}
+
+	req := new(http.Request)
+	ctx = setRequest(ctx, req)
+	*req = *r.WithContext(ctx)
+	return ctx, nil
+}
+
 // New creates a new initialized router
 func New() *mux.Router {
 	r := mux.NewRouter()
@@ -3,16 +3,29 @@
 package rest
 
 import (
-	"github.com/bradberger/context"
+	"context"
+	"net/http"
 
 	"google.golang.org/appengine"
+	"google.golang.org/appengine/datastore"
 )
 
-func setNamespace(ctx context.Context) (context.Context, error) {
-	if Namespace == nil {
+// init maps the App Engine errors to status codes for GetErrorCode
+func init() {
+	ErrorCodes.Register(datastore.ErrNoSuchEntity, http.StatusNotFound)
+	ErrorCodes.RegisterFunc(appengine.IsOverQuota, http.StatusTooManyRequests)
+	ErrorCodes.RegisterFunc(appengine.IsTimeoutError, http.StatusGatewayTimeout)
+}
+
+func newContext(r *http.Request) context.Context {
+	return appengine.WithContext(r.Context(), r)
+}
+
+func setNamespace(ctx context.Context, namespace func(ctx context.Context) (string, error)) (context.Context, error) {
+	if namespace == nil {
 		return ctx, nil
 	}
-	ns, err := Namespace(ctx)
+	ns, err := namespace(ctx)
 	if err != nil {
 		return ctx, err
 	}
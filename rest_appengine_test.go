@@ -3,7 +3,8 @@
 package rest
 
 import (
-	"github.com/bradberger/context"
+	"context"
+
 	"google.golang.org/appengine/aetest"
 )
 
@@ -3,9 +3,14 @@
 package rest
 
 import (
-	"github.com/bradberger/context"
+	"context"
+	"net/http"
 )
 
-func setNamespace(ctx context.Context) (context.Context, error) {
+func newContext(r *http.Request) context.Context {
+	return r.Context()
+}
+
+func setNamespace(ctx context.Context, namespace func(ctx context.Context) (string, error)) (context.Context, error) {
 	return ctx, nil
 }
@@ -1 +1,184 @@
 package rest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(h http.Handler, method, urlStr string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(method, urlStr, nil)
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func panicChain(pos, length int, calls *[]int) []AppHandler {
+	fns := make([]AppHandler, length)
+	for i := range fns {
+		i := i
+		fns[i] = func(ctx context.Context) error {
+			*calls = append(*calls, i)
+			if i == pos {
+				panic("boom")
+			}
+			return nil
+		}
+	}
+	return fns
+}
+
+func TestHandlerPanicOnError(t *testing.T) {
+	for _, pos := range []int{0, 1, 2} {
+		var calls []int
+		var gotErr error
+		var gotCode int
+
+		onError := OnError
+		OnError = func(ctx context.Context, code int, err error) {
+			gotCode, gotErr = code, err
+			http.Error(ResponseWriter(ctx), err.Error(), code)
+		}
+
+		w := serve(Handler(panicChain(pos, 3, &calls)...), "GET", "/")
+		OnError = onError
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, gotCode)
+		assert.Len(t, calls, pos+1)
+
+		var perr *PanicError
+		if assert.True(t, errors.As(gotErr, &perr)) {
+			assert.Equal(t, "boom", perr.Value)
+			assert.NotEmpty(t, perr.Stack)
+		}
+	}
+}
+
+func TestHandlerPanicOnPanic(t *testing.T) {
+	defer func() { OnPanic = nil }()
+
+	for _, pos := range []int{0, 1, 2} {
+		var calls []int
+		var recovered interface{}
+		var stack []byte
+
+		OnPanic = func(ctx context.Context) error {
+			recovered, stack = Recovered(ctx), Stack(ctx)
+			return Text(ctx, http.StatusServiceUnavailable, "recovered")
+		}
+
+		w := serve(Handler(panicChain(pos, 3, &calls)...), "GET", "/")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "recovered", w.Body.String())
+		assert.Equal(t, "boom", recovered)
+		assert.NotEmpty(t, stack)
+		assert.Len(t, calls, pos+1)
+	}
+}
+
+func TestHandlerPanicOnPanicError(t *testing.T) {
+	defer func() { OnPanic = nil }()
+	OnPanic = func(ctx context.Context) error {
+		return StatusBadGateway
+	}
+
+	var calls []int
+	w := serve(Handler(panicChain(1, 3, &calls)...), "GET", "/")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestHandlerNoPanic(t *testing.T) {
+	var calls []int
+	w := serve(Handler(panicChain(-1, 3, &calls)...), "GET", "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []int{0, 1, 2}, calls)
+	assert.Nil(t, Recovered(context.Background()))
+	assert.Nil(t, Stack(context.Background()))
+}
+
+func TestHandlerInitErrors(t *testing.T) {
+	var gotErr error
+	onError := OnError
+	defer func() { OnError = onError }()
+	OnError = func(ctx context.Context, code int, err error) {
+		gotErr = err
+		http.Error(ResponseWriter(ctx), err.Error(), code)
+	}
+
+	called := false
+	h := Handler(func(ctx context.Context) error {
+		called = true
+		return nil
+	})
+
+	w := serve(h, "GET", "/?q=%zz")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.False(t, called)
+	var initErr *InitError
+	if assert.True(t, errors.As(gotErr, &initErr)) {
+		assert.Equal(t, "form", initErr.Op)
+	}
+}
+
+type testContextKey string
+
+func TestInitRequestContext(t *testing.T) {
+	parent, cancel := context.WithCancel(context.WithValue(context.Background(), testContextKey("foo"), "bar"))
+	r := httptest.NewRequest("GET", "/", nil).WithContext(parent)
+	ctx := Init(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "bar", ctx.Value(testContextKey("foo")))
+	assert.NotNil(t, Request(ctx).Context().Value(ContextKeyResponseWriter))
+
+	cancel()
+	<-ctx.Done()
+	assert.Equal(t, context.Canceled, ctx.Err())
+}
+
+func TestCompat(t *testing.T) {
+	type legacyContext interface {
+		context.Context
+	}
+
+	var got legacyContext
+	h := Compat(func(ctx legacyContext) error {
+		got = ctx
+		return nil
+	})
+	assert.NoError(t, h(context.Background()))
+	assert.NotNil(t, got)
+}
+
+func TestFormValues(t *testing.T) {
+	var ctx context.Context
+	r := NewRouter()
+	r.POST("/items/{tag}", func(c context.Context) error {
+		ctx = c
+		return nil
+	})
+
+	req := httptest.NewRequest("POST", "/items/path?tag=q1&tag=q2&name=query&color=red&color=blue", strings.NewReader("name=form&size=s&size=m"))
+	req.Header.Set(HeaderContentType, MIMEApplicationForm.String())
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "path", PathValue(ctx, "tag"))
+	assert.Equal(t, "q1", QueryValue(ctx, "tag"))
+	assert.Equal(t, []string{"q1", "q2"}, QueryValues(ctx, "tag"))
+	assert.Equal(t, "form", PostFormValue(ctx, "name"))
+	assert.Equal(t, "", PostFormValue(ctx, "tag"))
+
+	assert.Equal(t, []string{"path"}, FormValues(ctx, "tag"))
+	assert.Equal(t, []string{"form"}, FormValues(ctx, "name"))
+	assert.Equal(t, []string{"s", "m"}, FormValues(ctx, "size"))
+	assert.Equal(t, []string{"red", "blue"}, FormValues(ctx, "color"))
+	assert.Nil(t, FormValues(ctx, "missing"))
+
+	assert.Equal(t, "path", FormValue(ctx, "tag"))
+	assert.Equal(t, "form", FormValue(ctx, "name"))
+}
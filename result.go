@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"context"
+	"reflect"
+)
+
+// ResultHandler is a handler which returns the response value instead of writing it. Use
+// Result() to turn it into an AppHandler.
+type ResultHandler func(ctx context.Context) (interface{}, error)
+
+// Result returns an AppHandler which writes the value returned by fn to the response with
+// WriteResult(), unless an error is returned.
+func Result(fn ResultHandler) AppHandler {
+	return func(ctx context.Context) error {
+		v, err := fn(ctx)
+		if err != nil {
+			return err
+		}
+		return WriteResult(ctx, v)
+	}
+}
+
+// WriteResult writes v to the response. The status code is taken from the ResponseCode
+// interface if v implements it, otherwise it's the code set with SetCode(), or 200. The
+// content-type is taken from the ResponseContentType interface if v implements it. The body
+// is taken from the ResponseReader, ResponseString or ResponseBytes interfaces, otherwise v
+// is encoded with Render(), or with the encoder for its content-type if it has one. A nil
+// value, including a nil pointer, results in a 204 response.
+func WriteResult(ctx context.Context, v interface{}) error {
+	if v == nil {
+		return NoContent(ctx)
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return NoContent(ctx)
+	}
+
+	code := GetCode(ctx)
+	if c, ok := v.(ResponseCode); ok {
+		code = c.Code()
+	}
+
+	var body interface{}
+	switch b := v.(type) {
+	case ResponseReader:
+		body = b.Body()
+	case ResponseString:
+		body = b.Body()
+	case ResponseBytes:
+		body = b.Body()
+	default:
+		if ct, ok := v.(ResponseContentType); ok {
+			return renderContentType(ctx, code, ct.ContentType(), v)
+		}
+		return Render(ctx, code, v)
+	}
+
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
+	if ct, ok := v.(ResponseContentType); ok {
+		w.Header().Set("Content-Type", ct.ContentType())
+	}
+	w.WriteHeader(code)
+	return write(w, body)
+}
@@ -0,0 +1,135 @@
+package rest
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testResult struct {
+	XMLName xml.Name `json:"-" xml:"result"`
+	Name    string   `json:"name" xml:"name"`
+}
+
+type testCreated struct {
+	testResult
+}
+
+func (testCreated) Code() int {
+	return http.StatusCreated
+}
+
+type testCSV string
+
+func (testCSV) ContentType() string {
+	return "text/csv"
+}
+
+func (c testCSV) Body() string {
+	return string(c)
+}
+
+type testVendor struct {
+	Name string `json:"name"`
+}
+
+func (testVendor) ContentType() string {
+	return "application/vnd.example.user+json"
+}
+
+type testCSVRecord struct {
+	Name string
+}
+
+func (testCSVRecord) ContentType() string {
+	return "text/csv"
+}
+
+func serveResult(fn ResultHandler, accept string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(HeaderAccept, accept)
+	Handler(Result(fn)).ServeHTTP(w, r)
+	return w
+}
+
+func TestResult(t *testing.T) {
+	w := serveResult(func(ctx context.Context) (interface{}, error) {
+		return &testResult{Name: "foo"}, nil
+	}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, MIMEApplicationJSONCharsetUTF8.String(), w.Header().Get(HeaderContentType))
+	assert.Equal(t, `{"name":"foo"}`, strings.TrimSpace(w.Body.String()))
+
+	w = serveResult(func(ctx context.Context) (interface{}, error) {
+		return &testResult{Name: "foo"}, nil
+	}, "application/xml")
+	assert.Equal(t, "<result><name>foo</name></result>", w.Body.String())
+
+	w = serveResult(func(ctx context.Context) (interface{}, error) {
+		return testCreated{testResult{Name: "bar"}}, nil
+	}, "")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"name":"bar"}`, strings.TrimSpace(w.Body.String()))
+
+	w = serveResult(func(ctx context.Context) (interface{}, error) {
+		return testCSV("a,b\n"), nil
+	}, "")
+	assert.Equal(t, "text/csv", w.Header().Get(HeaderContentType))
+	assert.Equal(t, "a,b\n", w.Body.String())
+
+	w = serveResult(func(ctx context.Context) (interface{}, error) {
+		return StatusAccepted, nil
+	}, "")
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "Accepted", w.Body.String())
+
+	w = serveResult(func(ctx context.Context) (interface{}, error) {
+		SetCode(ctx, http.StatusCreated)
+		return &testResult{Name: "baz"}, nil
+	}, "")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"name":"baz"}`, strings.TrimSpace(w.Body.String()))
+
+	w = serveResult(func(ctx context.Context) (interface{}, error) {
+		return nil, nil
+	}, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serveResult(func(ctx context.Context) (interface{}, error) {
+		var r *testResult
+		return r, nil
+	}, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = serveResult(func(ctx context.Context) (interface{}, error) {
+		return testVendor{Name: "foo"}, nil
+	}, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/vnd.example.user+json", w.Header().Get(HeaderContentType))
+	assert.Equal(t, `{"name":"foo"}`, strings.TrimSpace(w.Body.String()))
+
+	w = serveResult(func(ctx context.Context) (interface{}, error) {
+		return &testResult{}, StatusNotFound
+	}, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestResultNoEncoder(t *testing.T) {
+	var gotErr error
+	w := httptest.NewRecorder()
+	Handler(func(ctx context.Context) error {
+		gotErr = WriteResult(ctx, testCSVRecord{Name: "foo"})
+		return gotErr
+	}).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.True(t, errors.Is(gotErr, ErrNoEncoder))
+	assert.Contains(t, gotErr.Error(), "text/csv")
+}
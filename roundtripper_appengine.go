@@ -3,11 +3,10 @@
 package rest
 
 import (
+	"context"
 	"net/http"
 
 	"google.golang.org/appengine/urlfetch"
-
-	"github.com/bradberger/context"
 )
 
 // TestClient creates a http.Client which will return the given response
@@ -3,9 +3,8 @@
 package rest
 
 import (
+	"context"
 	"net/http"
-
-	"github.com/bradberger/context"
 )
 
 // TestClient creates a http.Client which will return the given response
@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	_ http.Handler = (*Router)(nil)
+)
+
+// Router wraps a mux.Router, building the Handler chain for each route from the router's
+// middleware and the route's handlers. Middleware and settings are applied to routes when
+// they're added, so they should be set up before adding routes.
+type Router struct {
+	// MaxBodySize limits the size of the request body for routes added to the router.
+	// Zero means no limit.
+	MaxBodySize int64
+	// Timeout limits the time a route added to the router may take to respond, after
+	// which a 503 is returned. Zero means no timeout.
+	Timeout time.Duration
+
+	app        *App
+	mux        *mux.Router
+	middleware []AppHandler
+	after      []AfterFunc
+}
+
+// NewRouter creates a new Router
+func NewRouter() *Router {
+	return &Router{mux: New()}
+}
+
+// Use adds middleware to the router which runs before the handlers of every route added afterwards
+func (r *Router) Use(mw ...AppHandler) {
+	r.middleware = append(r.middleware, mw...)
+}
+
+// After adds funcs which run after the response of every route added afterwards has been written,
+// even if a handler returns an error or panics. They're run in reverse order, after any funcs
+// registered with Defer() during the request.
+func (r *Router) After(fn ...AfterFunc) {
+	r.after = append(r.after, fn...)
+}
+
+// Group returns a new Router for routes under the given path prefix. It inherits the middleware
+// and settings of r, which can then be changed without affecting r. An empty prefix can be used
+// to give a set of routes different middleware or settings.
+func (r *Router) Group(prefix string, mw ...AppHandler) *Router {
+	g := &Router{
+		MaxBodySize: r.MaxBodySize,
+		Timeout:     r.Timeout,
+		app:         r.app,
+		mux:         r.mux,
+		middleware:  append(append([]AppHandler{}, r.middleware...), mw...),
+		after:       append([]AfterFunc{}, r.after...),
+	}
+	if prefix != "" {
+		g.mux = r.mux.PathPrefix(prefix).Subrouter()
+	}
+	return g
+}
+
+// Handle adds a route for the given method and path, with the router middleware followed by h.
+// The route uses the hooks of the App the router belongs to, or DefaultApp.
+func (r *Router) Handle(method, path string, h ...AppHandler) *mux.Route {
+	app := r.app
+	if app == nil {
+		app = DefaultApp
+	}
+	hf := app.handler(r.after, append(append([]AppHandler{}, r.middleware...), h...))
+	if r.MaxBodySize > 0 {
+		hf = MaxBodySize(r.MaxBodySize)(hf)
+	}
+	if r.Timeout > 0 {
+		hf = withTimeout(http.TimeoutHandler(hf, r.Timeout, http.StatusText(http.StatusServiceUnavailable)))
+	}
+	return r.mux.Handle(path, hf).Methods(method)
+}
+
+// withTimeout marks the request as having a timeout, so the AfterFuncs get a 503 if it passes
+func withTimeout(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(setValue(r.Context(), contextKeyTimeout, true)))
+	})
+}
+
+// GET adds a route for GET requests
+func (r *Router) GET(path string, h ...AppHandler) *mux.Route {
+	return r.Handle(GET, path, h...)
+}
+
+// HEAD adds a route for HEAD requests
+func (r *Router) HEAD(path string, h ...AppHandler) *mux.Route {
+	return r.Handle(HEAD, path, h...)
+}
+
+// OPTIONS adds a route for OPTIONS requests
+func (r *Router) OPTIONS(path string, h ...AppHandler) *mux.Route {
+	return r.Handle(OPTIONS, path, h...)
+}
+
+// POST adds a route for POST requests
+func (r *Router) POST(path string, h ...AppHandler) *mux.Route {
+	return r.Handle(POST, path, h...)
+}
+
+// PUT adds a route for PUT requests
+func (r *Router) PUT(path string, h ...AppHandler) *mux.Route {
+	return r.Handle(PUT, path, h...)
+}
+
+// PATCH adds a route for PATCH requests
+func (r *Router) PATCH(path string, h ...AppHandler) *mux.Route {
+	return r.Handle(PATCH, path, h...)
+}
+
+// DELETE adds a route for DELETE requests
+func (r *Router) DELETE(path string, h ...AppHandler) *mux.Route {
+	return r.Handle(DELETE, path, h...)
+}
+
+// Mux returns the underlying mux.Router
+func (r *Router) Mux() *mux.Router {
+	return r.mux
+}
+
+// ServeHTTP implements the http.Handler interface
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mux.ServeHTTP(w, req)
+}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func appendName(calls *[]string, name string) AppHandler {
+	return func(ctx context.Context) error {
+		*calls = append(*calls, name)
+		return nil
+	}
+}
+
+func TestRouter(t *testing.T) {
+	var calls []string
+
+	r := NewRouter()
+	r.Use(appendName(&calls, "root"))
+	r.GET("/ping", appendName(&calls, "ping"))
+
+	api := r.Group("/api", appendName(&calls, "api"))
+	api.POST("/users/{id}", func(ctx context.Context) error {
+		return Text(ctx, http.StatusCreated, FormValue(ctx, "id"))
+	})
+
+	w := serve(r, "GET", "/ping")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"root", "ping"}, calls)
+
+	calls = nil
+	w = serve(r, "POST", "/api/users/123")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "123", w.Body.String())
+	assert.Equal(t, []string{"root", "api"}, calls)
+
+	w = serve(r, "GET", "/api/users/123")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.NotNil(t, r.Mux())
+}
+
+func TestRouterSettings(t *testing.T) {
+	r := NewRouter()
+	r.MaxBodySize = 5
+	r.PUT("/upload", func(ctx context.Context) error {
+		_, err := ReadBody(ctx)
+		return err
+	})
+
+	after := make(chan afterCall, 1)
+	slow := r.Group("")
+	slow.Timeout = 10 * time.Millisecond
+	slow.After(func(ctx context.Context, code int, err error) {
+		after <- afterCall{"slow", code, err}
+	})
+	slow.GET("/slow", func(ctx context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("PUT", "/upload", strings.NewReader("0123456789")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	assert.Equal(t, http.StatusServiceUnavailable, serve(r, "GET", "/slow").Code)
+	assert.Equal(t, afterCall{"slow", http.StatusServiceUnavailable, http.ErrHandlerTimeout}, <-after)
+}
@@ -0,0 +1,210 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	// StrictDecoding turns on strict mode for all JSON decoded with Decode(). Use DecodeStrict()
+	// to turn it on for a single request, or App.StrictDecoding for an app.
+	StrictDecoding bool
+	// MaxDecodeDepth is the maximum nesting depth of JSON objects and arrays in strict mode.
+	// Zero means no limit. App.MaxDecodeDepth overrides it.
+	MaxDecodeDepth int
+)
+
+// Strict mode errors
+var (
+	ErrTrailingData     = errors.New("unexpected data after JSON value")
+	ErrMaxDepthExceeded = errors.New("maximum nesting depth exceeded")
+)
+
+// DecodeStrict works like Decode(), but decodes JSON in strict mode: unknown fields and data
+// after the JSON value are rejected, and the nesting depth is limited to MaxDecodeDepth.
+// Failures are returned as a *DecodeError, which results in a 400 response.
+func DecodeStrict(ctx context.Context, dst interface{}) error {
+	return Decode(setValue(ctx, contextKeyStrict, true), dst)
+}
+
+func unmarshalJSONStrict(b []byte, dst interface{}, maxDepth int) error {
+	if maxDepth > 0 {
+		if err := checkJSONDepth(b, maxDepth); err != nil {
+			return err
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		return jsonDecodeError(b, err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return &DecodeError{Offset: dec.InputOffset(), Err: ErrTrailingData}
+	}
+	return nil
+}
+
+// jsonDecodeError converts errors from encoding/json into a *DecodeError. An invalid
+// destination is a bug in the handler rather than bad input, so it's returned as is.
+func jsonDecodeError(b []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var invalidErr *json.InvalidUnmarshalError
+
+	switch {
+	case errors.As(err, &invalidErr):
+		return err
+	case errors.As(err, &syntaxErr):
+		return &DecodeError{Pointer: jsonPointerAt(b, syntaxErr.Offset), Offset: syntaxErr.Offset, Err: err}
+	case errors.As(err, &typeErr):
+		return &DecodeError{
+			Field:    typeErr.Field,
+			Pointer:  jsonPointerAt(b, typeErr.Offset),
+			Offset:   typeErr.Offset,
+			Expected: typeErr.Type.String(),
+			Err:      err,
+		}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), "json: unknown field "))
+		pointer, offset := jsonKeyPointer(b, name)
+		return &DecodeError{Field: name, Pointer: pointer, Offset: offset, Err: err}
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return &DecodeError{Offset: int64(len(b)), Err: io.ErrUnexpectedEOF}
+	default:
+		return &DecodeError{Err: err}
+	}
+}
+
+// jsonFrame is an object or array being walked by walkJSON
+type jsonFrame struct {
+	object  bool
+	wantKey bool
+	key     string
+	index   int
+}
+
+func (f *jsonFrame) next() {
+	if f.object {
+		f.wantKey = true
+	} else {
+		f.index++
+	}
+}
+
+// walkJSON calls fn with the path and offset of each value and object key in b, until fn returns false
+func walkJSON(b []byte, fn func(path []string, offset int64, isKey, isContainer bool) bool) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	var stack []*jsonFrame
+
+	path := func() []string {
+		p := make([]string, len(stack))
+		for i, f := range stack {
+			if f.object {
+				p[i] = f.key
+			} else {
+				p[i] = strconv.Itoa(f.index)
+			}
+		}
+		return p
+	}
+
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				stack[len(stack)-1].next()
+			}
+			continue
+		}
+
+		if top != nil && top.object && top.wantKey {
+			top.key, top.wantKey = tok.(string), false
+			if !fn(path(), offset, true, false) {
+				return nil
+			}
+			continue
+		}
+
+		d, isContainer := tok.(json.Delim)
+		if !fn(path(), offset, false, isContainer) {
+			return nil
+		}
+		if isContainer {
+			stack = append(stack, &jsonFrame{object: d == '{', wantKey: d == '{'})
+		} else if top != nil {
+			top.next()
+		}
+	}
+}
+
+// checkJSONDepth returns a *DecodeError if objects or arrays in b are nested deeper than max.
+// The depth of a container is the number of containers it's in plus one, so the top level
+// value is at depth 1.
+func checkJSONDepth(b []byte, max int) error {
+	var derr *DecodeError
+	walkJSON(b, func(path []string, offset int64, isKey, isContainer bool) bool {
+		if isContainer && len(path)+1 > max {
+			derr = &DecodeError{Pointer: jsonPointer(path), Offset: offset, Err: ErrMaxDepthExceeded}
+			return false
+		}
+		return true
+	})
+	if derr != nil {
+		return derr
+	}
+	return nil
+}
+
+// jsonPointerAt returns the JSON pointer of the last value which starts before offset
+func jsonPointerAt(b []byte, offset int64) string {
+	var pointer string
+	walkJSON(b, func(path []string, off int64, isKey, isContainer bool) bool {
+		if off >= offset {
+			return false
+		}
+		pointer = jsonPointer(path)
+		return true
+	})
+	return pointer
+}
+
+// jsonKeyPointer returns the JSON pointer and offset of the first object key with the given name
+func jsonKeyPointer(b []byte, name string) (pointer string, offset int64) {
+	walkJSON(b, func(path []string, off int64, isKey, isContainer bool) bool {
+		if isKey && path[len(path)-1] == name {
+			pointer, offset = jsonPointer(path), off
+			return false
+		}
+		return true
+	})
+	return
+}
+
+func jsonPointer(path []string) string {
+	var buf strings.Builder
+	for _, p := range path {
+		buf.WriteByte('/')
+		buf.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(p))
+	}
+	return buf.String()
+}
@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testStrict struct {
+	Name  string `json:"name"`
+	Items []struct {
+		Age int `json:"age"`
+	} `json:"items"`
+}
+
+func decodeStrictError(t *testing.T, body string) *DecodeError {
+	r, err := TestPost("/", "application/json", strings.NewReader(body))
+	assert.NoError(t, err)
+
+	var dst testStrict
+	err = DecodeStrict(r.Context, &dst)
+	var decodeErr *DecodeError
+	if !assert.True(t, errors.As(err, &decodeErr), "%v", err) {
+		return &DecodeError{}
+	}
+	assert.Equal(t, http.StatusBadRequest, decodeErr.Code())
+	return decodeErr
+}
+
+func TestDecodeStrict(t *testing.T) {
+	r, err := TestPost("/", "application/json", strings.NewReader(`{"name":"foo","items":[{"age":1}]} `))
+	assert.NoError(t, err)
+	var dst testStrict
+	assert.NoError(t, DecodeStrict(r.Context, &dst))
+	assert.Equal(t, 1, dst.Items[0].Age)
+
+	body := `{"name":"foo","items":[{"age":1},{"age":"two"}]}`
+	e := decodeStrictError(t, body)
+	assert.Equal(t, "/items/1/age", e.Pointer)
+	assert.Equal(t, "int", e.Expected)
+	assert.Equal(t, int64(strings.Index(body, `"two"`)+len(`"two"`)), e.Offset)
+
+	e = decodeStrictError(t, `{"name":"foo","items":[{"age":1,"extra":true}]}`)
+	assert.Equal(t, "extra", e.Field)
+	assert.Equal(t, "/items/0/extra", e.Pointer)
+
+	e = decodeStrictError(t, `{"name":"foo"} {"name":"bar"}`)
+	assert.Equal(t, ErrTrailingData, e.Err)
+
+	e = decodeStrictError(t, `{"name":"foo",}`)
+	assert.Equal(t, int64(15), e.Offset)
+}
+
+func TestDecodeStrictDepth(t *testing.T) {
+	defer func() { MaxDecodeDepth = 0 }()
+	MaxDecodeDepth = 2
+
+	e := decodeStrictError(t, `{"items":[{"age":1}]}`)
+	assert.Equal(t, ErrMaxDepthExceeded, e.Err)
+	assert.Equal(t, "/items/0", e.Pointer)
+}
+
+func TestCheckJSONDepth(t *testing.T) {
+	assert.NoError(t, checkJSONDepth([]byte(`{"a":1}`), 1))
+	assert.NoError(t, checkJSONDepth([]byte(`"scalar"`), 1))
+
+	err := checkJSONDepth([]byte(`{"a":{}}`), 1)
+	var e *DecodeError
+	if assert.True(t, errors.As(err, &e)) {
+		assert.Equal(t, ErrMaxDepthExceeded, e.Err)
+		assert.Equal(t, "/a", e.Pointer)
+	}
+
+	assert.NoError(t, checkJSONDepth([]byte(`{"a":{"b":[1]}}`), 3))
+	assert.Error(t, checkJSONDepth([]byte(`{"a":{"b":[[1]]}}`), 3))
+}
+
+func TestStrictDecoding(t *testing.T) {
+	defer func() { StrictDecoding = false }()
+
+	r, err := TestPost("/", "application/json", strings.NewReader(`{"unknown":1}`))
+	assert.NoError(t, err)
+	var dst testStrict
+	assert.NoError(t, Decode(r.Context, &dst))
+
+	StrictDecoding = true
+	var decodeErr *DecodeError
+	assert.True(t, errors.As(Decode(r.Context, &dst), &decodeErr))
+}
@@ -0,0 +1,388 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// sniffLen is the number of bytes http.DetectContentType() looks at
+const sniffLen = 512
+
+var (
+	// ErrFileTooLarge is returned when an uploaded file is larger than UploadOptions.MaxFileSize
+	ErrFileTooLarge = errors.New("file too large")
+	// ErrUploadTooLarge is returned when the upload is larger than UploadOptions.MaxTotalSize
+	ErrUploadTooLarge = errors.New("upload too large")
+	// ErrTooManyFiles is returned when there are more files than UploadOptions.MaxFiles
+	ErrTooManyFiles = errors.New("too many files")
+	// ErrFileType is returned when the detected type of a file isn't in UploadOptions.AllowedTypes
+	ErrFileType = errors.New("file type not allowed")
+	// ErrNoStorage is returned when UploadOptions.Storage isn't set
+	ErrNoStorage = errors.New("no upload storage")
+	// ErrUploadNotFound is returned by the storage backends when a key doesn't exist
+	ErrUploadNotFound = errors.New("upload not found")
+)
+
+// UploadError is returned by Upload() when a part of the upload is rejected
+type UploadError struct {
+	Field    string
+	Filename string
+	Status   int
+	Err      error
+}
+
+func (e *UploadError) Error() string {
+	if e.Filename != "" {
+		return fmt.Sprintf("upload %s (%s): %v", e.Field, e.Filename, e.Err)
+	}
+	return fmt.Sprintf("upload %s: %v", e.Field, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *UploadError) Unwrap() error {
+	return e.Err
+}
+
+// Code returns the http status code for the error, which implements the ResponseCode interface
+func (e *UploadError) Code() int {
+	return e.Status
+}
+
+// Storage saves uploaded files. Save() must read r until it returns io.EOF or an error, and
+// must not keep anything it's saved if reading fails.
+type Storage interface {
+	Save(ctx context.Context, f *UploadedFile, r io.Reader) (key string, err error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// UploadOptions sets the limits for Upload(). A zero limit means there is no limit.
+type UploadOptions struct {
+	// MaxFileSize is the maximum size in bytes of each file
+	MaxFileSize int64
+	// MaxTotalSize is the maximum size in bytes of all the files and form values together
+	MaxTotalSize int64
+	// MaxFiles is the maximum number of files
+	MaxFiles int
+	// AllowedTypes are the MIME types allowed for files, detected from their content with
+	// http.DetectContentType(). Types like "image/*" match any subtype. If it's empty, all
+	// types are allowed.
+	AllowedTypes []string
+	// Storage saves the files
+	Storage Storage
+}
+
+// UploadedFile describes a file saved by Upload()
+type UploadedFile struct {
+	Field       string
+	Filename    string
+	ContentType string
+	Size        int64
+	Key         string
+}
+
+// Uploads is the result of Upload()
+type Uploads struct {
+	Files  []*UploadedFile
+	Values url.Values
+}
+
+// Upload streams a multipart/form-data request body, saving the files to opts.Storage as
+// they're read, so the whole body is never held in memory. Form values are returned in
+// Uploads.Values, and together they're limited to MaxMultipartMemory bytes. If any limit is exceeded, the files which were already saved are deleted
+// and an *UploadError is returned with a 413 or 415 status code. The body must not have
+// been read already, otherwise it's been buffered and there's nothing gained by streaming.
+func Upload(ctx context.Context, opts UploadOptions) (*Uploads, error) {
+	r := Request(ctx)
+	if r == nil {
+		return nil, ErrNoRequest
+	}
+	if opts.Storage == nil {
+		return nil, ErrNoStorage
+	}
+
+	mt, params, err := mime.ParseMediaType(r.Header.Get(HeaderContentType))
+	if err != nil || mt != MIMEMultipartForm.String() || params["boundary"] == "" {
+		return nil, StatusUnsupportedMediaType
+	}
+
+	u := &uploader{opts: opts, memory: AppFromContext(ctx).maxMultipartMemory(), result: &Uploads{Values: url.Values{}}}
+	if err := u.read(ctx, multipart.NewReader(BodyReader(ctx), params["boundary"])); err != nil {
+		for _, f := range u.result.Files {
+			opts.Storage.Delete(ctx, f.Key)
+		}
+		return nil, err
+	}
+	return u.result, nil
+}
+
+type uploader struct {
+	opts UploadOptions
+	// memory is what's left of the MaxMultipartMemory budget for form values
+	memory int64
+	total  int64
+	result *Uploads
+}
+
+func (u *uploader) read(ctx context.Context, mr *multipart.Reader) error {
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return u.error(nil, http.StatusBadRequest, err)
+		}
+
+		if p.FileName() == "" {
+			err = u.readValue(p)
+		} else {
+			err = u.readFile(ctx, p)
+		}
+		p.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (u *uploader) readValue(p *multipart.Part) error {
+	limit := u.memory
+	if u.opts.MaxTotalSize > 0 && u.opts.MaxTotalSize-u.total < limit {
+		limit = u.opts.MaxTotalSize - u.total
+	}
+	b, err := ioutil.ReadAll(io.LimitReader(p, limit+1))
+	if err != nil {
+		return u.error(p, http.StatusBadRequest, err)
+	}
+	if int64(len(b)) > limit {
+		return u.error(p, http.StatusRequestEntityTooLarge, ErrUploadTooLarge)
+	}
+	u.memory -= int64(len(b))
+	u.total += int64(len(b))
+	u.result.Values.Add(p.FormName(), string(b))
+	return nil
+}
+
+func (u *uploader) readFile(ctx context.Context, p *multipart.Part) error {
+	if u.opts.MaxFiles > 0 && len(u.result.Files) >= u.opts.MaxFiles {
+		return u.error(p, http.StatusRequestEntityTooLarge, ErrTooManyFiles)
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(p, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return u.error(p, http.StatusBadRequest, err)
+	}
+	head = head[:n]
+
+	f := &UploadedFile{
+		Field:       p.FormName(),
+		Filename:    filepath.Base(p.FileName()),
+		ContentType: http.DetectContentType(head),
+	}
+	if !allowedType(f.ContentType, u.opts.AllowedTypes) {
+		return u.error(p, http.StatusUnsupportedMediaType, ErrFileType)
+	}
+
+	lr := &uploadReader{r: io.MultiReader(bytes.NewReader(head), p), u: u}
+	f.Key, err = u.opts.Storage.Save(ctx, f, lr)
+	if lr.err != nil {
+		return u.error(p, http.StatusRequestEntityTooLarge, lr.err)
+	}
+	if err != nil {
+		return u.error(p, http.StatusInternalServerError, err)
+	}
+	f.Size = lr.n
+	u.result.Files = append(u.result.Files, f)
+	return nil
+}
+
+func (u *uploader) error(p *multipart.Part, status int, err error) error {
+	e := &UploadError{Status: status, Err: err}
+	if p != nil {
+		e.Field, e.Filename = p.FormName(), p.FileName()
+	}
+	var initErr *InitError
+	if errors.As(err, &initErr) {
+		e.Status = initErr.Status
+	}
+	return e
+}
+
+// uploadReader enforces the file and total size limits while the file is being saved
+type uploadReader struct {
+	r   io.Reader
+	u   *uploader
+	n   int64
+	err error
+}
+
+func (r *uploadReader) Read(b []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.r.Read(b)
+	r.n += int64(n)
+	r.u.total += int64(n)
+	switch {
+	case r.u.opts.MaxFileSize > 0 && r.n > r.u.opts.MaxFileSize:
+		r.err = ErrFileTooLarge
+	case r.u.opts.MaxTotalSize > 0 && r.u.total > r.u.opts.MaxTotalSize:
+		r.err = ErrUploadTooLarge
+	}
+	if r.err != nil {
+		return n, r.err
+	}
+	return n, err
+}
+
+func allowedType(contentType string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, a := range allowed {
+		if a == mt || (strings.HasSuffix(a, "/*") && strings.HasPrefix(mt, strings.TrimSuffix(a, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// newUploadKey returns a random key for a file. It doesn't have an extension, as the filename
+// comes from the client and can't be trusted to match the contents.
+func newUploadKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// FileStorage saves uploaded files in a directory on the local filesystem
+type FileStorage struct {
+	Dir string
+}
+
+// Save writes the file to a new file in the directory, and returns the file name as the key.
+// The file name is random and has no extension, whatever the name of the uploaded file.
+func (s *FileStorage) Save(ctx context.Context, f *UploadedFile, r io.Reader) (string, error) {
+	key, err := newUploadKey()
+	if err != nil {
+		return "", err
+	}
+
+	fh, err := os.OpenFile(filepath.Join(s.Dir, key), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(fh, r)
+	if closeErr := fh.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fh.Name())
+		return "", err
+	}
+	return key, nil
+}
+
+// Open opens the file with the key for reading
+func (s *FileStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	fh, err := os.Open(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrUploadNotFound
+	}
+	return fh, err
+}
+
+// Delete removes the file with the key
+func (s *FileStorage) Delete(ctx context.Context, key string) error {
+	err := os.Remove(s.path(key))
+	if os.IsNotExist(err) {
+		return ErrUploadNotFound
+	}
+	return err
+}
+
+func (s *FileStorage) path(key string) string {
+	return filepath.Join(s.Dir, filepath.Base(key))
+}
+
+// MemoryStorage keeps uploaded files in memory. It's meant for tests. The zero value is ready to use.
+type MemoryStorage struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemoryStorage returns an empty MemoryStorage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{files: map[string][]byte{}}
+}
+
+// Save reads the file into memory
+func (s *MemoryStorage) Save(ctx context.Context, f *UploadedFile, r io.Reader) (string, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	key, err := newUploadKey()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.files == nil {
+		s.files = map[string][]byte{}
+	}
+	s.files[key] = b
+	return key, nil
+}
+
+// Open returns a reader for the file with the key
+func (s *MemoryStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.files[key]
+	if !ok {
+		return nil, ErrUploadNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(b)), nil
+}
+
+// Delete removes the file with the key
+func (s *MemoryStorage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[key]; !ok {
+		return ErrUploadNotFound
+	}
+	delete(s.files, key)
+	return nil
+}
+
+// Len returns the number of files stored
+func (s *MemoryStorage) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.files)
+}
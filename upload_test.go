@@ -0,0 +1,207 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPNG = []byte("\x89PNG\x0d\x0a\x1a\x0a" + "\x00\x00\x00\x0dIHDR")
+
+type testUploadFile struct {
+	field, name string
+	data        []byte
+}
+
+func uploadRequest(opts UploadOptions, files ...testUploadFile) (*Uploads, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("title", "holiday")
+	for _, f := range files {
+		fw, _ := mw.CreateFormFile(f.field, f.name)
+		fw.Write(f.data)
+	}
+	mw.Close()
+
+	r := httptest.NewRequest("POST", "/", &buf)
+	r.Header.Set(HeaderContentType, mw.FormDataContentType())
+	return Upload(Init(httptest.NewRecorder(), r), opts)
+}
+
+func TestUpload(t *testing.T) {
+	store := NewMemoryStorage()
+	up, err := uploadRequest(UploadOptions{Storage: store, AllowedTypes: []string{"image/*", "text/plain"}},
+		testUploadFile{"photo", "a.png", testPNG},
+		testUploadFile{"notes", "../../notes.txt", []byte("hello")},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "holiday", up.Values.Get("title"))
+	if assert.Len(t, up.Files, 2) {
+		assert.Equal(t, "photo", up.Files[0].Field)
+		assert.Equal(t, "image/png", up.Files[0].ContentType)
+		assert.Equal(t, int64(len(testPNG)), up.Files[0].Size)
+		assert.Equal(t, "notes.txt", up.Files[1].Filename)
+		assert.Equal(t, "text/plain; charset=utf-8", up.Files[1].ContentType)
+
+		rc, err := store.Open(context.Background(), up.Files[1].Key)
+		if assert.NoError(t, err) {
+			b, _ := ioutil.ReadAll(rc)
+			assert.Equal(t, "hello", string(b))
+		}
+	}
+}
+
+func TestUploadLimits(t *testing.T) {
+	big := bytes.Repeat([]byte("a"), 1024)
+	tests := []struct {
+		opts   UploadOptions
+		files  []testUploadFile
+		status int
+		err    error
+	}{
+		{UploadOptions{MaxFileSize: 100}, []testUploadFile{{"a", "a.txt", []byte("ok")}, {"b", "b.txt", big}}, http.StatusRequestEntityTooLarge, ErrFileTooLarge},
+		{UploadOptions{MaxTotalSize: 1500}, []testUploadFile{{"a", "a.txt", big}, {"b", "b.txt", big}}, http.StatusRequestEntityTooLarge, ErrUploadTooLarge},
+		{UploadOptions{MaxFiles: 1}, []testUploadFile{{"a", "a.txt", []byte("a")}, {"b", "b.txt", []byte("b")}}, http.StatusRequestEntityTooLarge, ErrTooManyFiles},
+		{UploadOptions{AllowedTypes: []string{"image/png"}}, []testUploadFile{{"a", "a.png", testPNG}, {"b", "b.png", []byte("not a png")}}, http.StatusUnsupportedMediaType, ErrFileType},
+	}
+
+	for _, tt := range tests {
+		store := NewMemoryStorage()
+		tt.opts.Storage = store
+		_, err := uploadRequest(tt.opts, tt.files...)
+
+		var upErr *UploadError
+		if assert.True(t, errors.As(err, &upErr), "%v", tt.err) {
+			assert.Equal(t, tt.status, upErr.Code())
+			assert.Equal(t, "b", upErr.Field)
+			assert.True(t, errors.Is(err, tt.err))
+		}
+		assert.Equal(t, 0, store.Len(), "files should be deleted after an error")
+	}
+}
+
+func TestUploadNotMultipart(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", bytes.NewBufferString("{}"))
+	r.Header.Set(HeaderContentType, MIMEApplicationJSON.String())
+	_, err := Upload(Init(httptest.NewRecorder(), r), UploadOptions{Storage: NewMemoryStorage()})
+	assert.Equal(t, StatusUnsupportedMediaType, err)
+
+	_, err = Upload(Init(httptest.NewRecorder(), r), UploadOptions{})
+	assert.Equal(t, ErrNoStorage, err)
+}
+
+func TestFileStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rest-upload")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	store := &FileStorage{Dir: dir}
+	up, err := uploadRequest(UploadOptions{Storage: store}, testUploadFile{"photo", "a.png", testPNG})
+	if !assert.NoError(t, err) || !assert.Len(t, up.Files, 1) {
+		return
+	}
+
+	ctx := context.Background()
+	rc, err := store.Open(ctx, up.Files[0].Key)
+	if assert.NoError(t, err) {
+		b, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		assert.Equal(t, testPNG, b)
+	}
+
+	assert.NoError(t, store.Delete(ctx, up.Files[0].Key))
+	_, err = store.Open(ctx, up.Files[0].Key)
+	assert.Equal(t, ErrUploadNotFound, err)
+}
+
+func TestUploadAfterFormValues(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	mw.WriteField("title", "holiday")
+	fw, _ := mw.CreateFormFile("photo", "a.png")
+	fw.Write(testPNG)
+	mw.Close()
+
+	r := httptest.NewRequest("POST", "/?title=query", &buf)
+	r.Header.Set(HeaderContentType, mw.FormDataContentType())
+	ctx := Init(httptest.NewRecorder(), r)
+
+	// Multipart bodies aren't parsed by the accessors, so they agree and leave the body alone
+	assert.Equal(t, []string{"query"}, FormValues(ctx, "title"))
+	assert.Equal(t, "query", FormValue(ctx, "title"))
+	assert.Equal(t, "", PostFormValue(ctx, "title"))
+
+	up, err := Upload(ctx, UploadOptions{Storage: NewMemoryStorage()})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "holiday", up.Values.Get("title"))
+		assert.Len(t, up.Files, 1)
+	}
+}
+
+func TestMemoryStorageZeroValue(t *testing.T) {
+	var store MemoryStorage
+	ctx := context.Background()
+	_, err := store.Open(ctx, "missing")
+	assert.Equal(t, ErrUploadNotFound, err)
+	assert.Equal(t, 0, store.Len())
+
+	up, err := uploadRequest(UploadOptions{Storage: &store}, testUploadFile{"photo", "a.png", testPNG})
+	if assert.NoError(t, err) && assert.Len(t, up.Files, 1) {
+		assert.Equal(t, 1, store.Len())
+		assert.NoError(t, store.Delete(ctx, up.Files[0].Key))
+	}
+}
+
+func TestUploadValuesMemory(t *testing.T) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		mw.WriteField(name, "12345678")
+	}
+	mw.Close()
+
+	app := &App{MaxMultipartMemory: 10}
+	var err error
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/", &buf)
+	r.Header.Set(HeaderContentType, mw.FormDataContentType())
+	app.Handler(func(ctx context.Context) error {
+		_, err = Upload(ctx, UploadOptions{Storage: NewMemoryStorage()})
+		return nil
+	}).ServeHTTP(w, r)
+
+	var upErr *UploadError
+	if assert.True(t, errors.As(err, &upErr)) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, upErr.Code())
+		assert.Equal(t, "b", upErr.Field)
+		assert.True(t, errors.Is(err, ErrUploadTooLarge))
+	}
+}
+
+func TestFileStorageKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rest-upload")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	up, err := uploadRequest(UploadOptions{Storage: &FileStorage{Dir: dir}}, testUploadFile{"doc", "shell.php", []byte("<?php echo 1; ?>")})
+	if assert.NoError(t, err) && assert.Len(t, up.Files, 1) {
+		assert.Equal(t, "shell.php", up.Files[0].Filename)
+		assert.Equal(t, "", filepath.Ext(up.Files[0].Key))
+	}
+}
@@ -1,9 +1,8 @@
 package rest
 
 import (
+	"context"
 	"errors"
-
-	"github.com/bradberger/context"
 )
 
 var (
@@ -13,9 +12,8 @@ var (
 	ErrNoUserFunc = errors.New("no user func defined")
 )
 
+// User gets the user associated with the current request, using the UserFunc of the App
+// handling the request
 func User(ctx context.Context, user interface{}) error {
-	if UserFunc == nil {
-		return ErrNoUserFunc
-	}
-	return UserFunc(ctx, user)
+	return AppFromContext(ctx).User(ctx, user)
 }
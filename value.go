@@ -0,0 +1,34 @@
+package rest
+
+import (
+	"context"
+	"fmt"
+)
+
+// contextKey is the type of the context keys defined by the package. Since it's unexported,
+// the keys can't collide with keys defined in other packages.
+type contextKey struct {
+	name string
+}
+
+func (k *contextKey) String() string {
+	return "rest context key " + k.name
+}
+
+// Value returns the value for key in ctx as a T. The bool is false if there's no value
+// for the key or if it isn't a T.
+func Value[T any](ctx context.Context, key interface{}) (T, bool) {
+	v, ok := ctx.Value(key).(T)
+	return v, ok
+}
+
+// MustValue returns the value for key in ctx as a T, and panics if there's no value for
+// the key or it isn't a T.
+func MustValue[T any](ctx context.Context, key interface{}) T {
+	v, ok := Value[T](ctx, key)
+	if !ok {
+		var zero T
+		panic(fmt.Sprintf("rest: context value for %v is not a %T", key, zero))
+	}
+	return v
+}
@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValue(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ContextKeyEnvironment, "development")
+
+	env, ok := Value[string](ctx, ContextKeyEnvironment)
+	assert.True(t, ok)
+	assert.Equal(t, "development", env)
+
+	n, ok := Value[int](ctx, ContextKeyEnvironment)
+	assert.False(t, ok)
+	assert.Equal(t, 0, n)
+
+	assert.Equal(t, "development", MustValue[string](ctx, ContextKeyEnvironment))
+	assert.Panics(t, func() { MustValue[string](ctx, ContextKeyNamepace) })
+
+	// String keys from other packages don't collide with the package keys.
+	ctx = context.WithValue(context.Background(), "environment", "production")
+	_, ok = Value[string](ctx, ContextKeyEnvironment)
+	assert.False(t, ok)
+}
+
+func TestAccessorsWithoutRequest(t *testing.T) {
+	ctx := context.Background()
+	assert.NotPanics(t, func() {
+		assert.Nil(t, ResponseWriter(ctx))
+		assert.Nil(t, Request(ctx))
+		assert.Equal(t, http.Header{}, Header(ctx))
+		assert.Equal(t, http.Header{}, Headers(ctx))
+		assert.Equal(t, []byte{}, Body(ctx))
+		assert.Equal(t, "", FormValue(ctx, "foo"))
+		assert.Equal(t, ErrNoResponseWriter, Text(ctx, http.StatusOK, "foo"))
+		assert.Equal(t, ErrNoResponseWriter, Redirect(ctx, "/", http.StatusFound))
+		_, _, err := FormFile(ctx, "foo")
+		assert.Equal(t, ErrNoRequest, err)
+	})
+}
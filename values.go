@@ -0,0 +1,148 @@
+package rest
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
+// decodeValues sets the fields of the struct pointed to by dst from values. The key for each
+// field is taken from the tag, or the json tag if it isn't set, or the field name.
+func decodeValues(values map[string][]string, dst interface{}, tag string) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("decode destination must be a pointer to a struct, not %T", dst)
+	}
+	return decodeStruct(values, v.Elem(), tag)
+}
+
+func decodeStruct(values map[string][]string, v reflect.Value, tag string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+
+		name, ok := fieldName(f, tag)
+		if !ok {
+			continue
+		}
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && name == "" {
+			if err := decodeStruct(values, v.Field(i), tag); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		vals, ok := values[name]
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		if err := setField(v.Field(i), vals); err != nil {
+			return &DecodeError{Field: name, Err: err}
+		}
+	}
+	return nil
+}
+
+// fieldName returns the name of the field from the tag, or the json tag. It returns false if
+// the field should be skipped, and an empty name if it's not set by either tag.
+func fieldName(f reflect.StructField, tag string) (string, bool) {
+	for _, key := range []string{tag, "json"} {
+		if v, ok := f.Tag.Lookup(key); ok {
+			name := strings.Split(v, ",")[0]
+			if name == "-" {
+				return "", false
+			}
+			if name != "" {
+				return name, true
+			}
+		}
+	}
+	return "", true
+}
+
+// setField sets the field from the string values, converting them to the field type
+func setField(v reflect.Value, vals []string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setField(v.Elem(), vals)
+	}
+
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(vals[0]))
+	}
+
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i := range vals {
+			if err := setField(s.Index(i), vals[i:i+1]); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+
+	return setString(v, vals[0])
+}
+
+func setString(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Slice:
+		v.SetBytes([]byte(s))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return errors.New("unsupported type " + v.Type().String())
+	}
+	return nil
+}
@@ -1,8 +1,7 @@
 package rest
 
 import (
-	"encoding/json"
-	"encoding/xml"
+	"context"
 	"fmt"
 	"image"
 	"image/jpeg"
@@ -10,13 +9,14 @@ import (
 	"io"
 
 	"net/http"
-
-	"github.com/bradberger/context"
 )
 
 // Text writes the string to the HTTP connection as text/plain content type
 func Text(ctx context.Context, code int, str interface{}) error {
-	w := ResponseWriter(ctx)
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(code)
 	return write(w, str)
@@ -34,13 +34,24 @@ func Status(ctx context.Context, code int) error {
 
 // Redirect redirects the request to the given location
 func Redirect(ctx context.Context, urlStr string, code int) error {
-	http.Redirect(ResponseWriter(ctx), Request(ctx), urlStr, code)
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
+	r := Request(ctx)
+	if r == nil {
+		return ErrNoRequest
+	}
+	http.Redirect(w, r, urlStr, code)
 	return nil
 }
 
 // HTML writes the raw HTML to the HTTP connection
 func HTML(ctx context.Context, code int, html interface{}) error {
-	w := ResponseWriter(ctx)
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
 	w.Header().Set("Content-Type", "text/html")
 	w.WriteHeader(code)
 	return write(w, html)
@@ -48,7 +59,10 @@ func HTML(ctx context.Context, code int, html interface{}) error {
 
 // CSS writes the raw CSS to the HTTP connection
 func CSS(ctx context.Context, code int, css interface{}) error {
-	w := ResponseWriter(ctx)
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
 	w.Header().Set("Content-Type", "text/css")
 	w.WriteHeader(code)
 	return write(w, css)
@@ -79,33 +93,32 @@ func write(w io.Writer, data interface{}) error {
 
 // JSON writes the encoded data to the HTTP connection
 func JSON(ctx context.Context, code int, data interface{}) error {
-	w := ResponseWriter(ctx)
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(code)
-	switch data.(type) {
-	case io.Reader, *string, string, *[]byte, []byte:
-		return write(w, data)
-	default:
-		return json.NewEncoder(w).Encode(data)
-	}
+	return encodeJSON(w, data)
 }
 
 // XML writes the XML encoded data to the HTTP connection
 func XML(ctx context.Context, code int, data interface{}) error {
-	w := ResponseWriter(ctx)
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
 	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
 	w.WriteHeader(code)
-	switch data.(type) {
-	case io.Reader, *string, string, *[]byte, []byte:
-		return write(w, data)
-	default:
-		return xml.NewEncoder(w).Encode(data)
-	}
+	return encodeXML(w, data)
 }
 
 // PNG writes the image to the HTTP connection
 func PNG(ctx context.Context, code int, img image.Image) error {
-	w := ResponseWriter(ctx)
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
 	w.Header().Set("Content-Type", "image/png")
 	enc := &png.Encoder{CompressionLevel: png.BestCompression}
 	return enc.Encode(w, img)
@@ -113,14 +126,20 @@ func PNG(ctx context.Context, code int, img image.Image) error {
 
 // JPEG writes the image to the HTTP connection
 func JPEG(ctx context.Context, code int, img image.Image) error {
-	w := ResponseWriter(ctx)
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
 	w.Header().Set("Content-Type", "image/jpeg")
 	return jpeg.Encode(w, img, &jpeg.Options{Quality: 100})
 }
 
 // NoContent handles responses without any content
 func NoContent(ctx context.Context) error {
-	w := ResponseWriter(ctx)
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusNoContent)
 	return nil
@@ -128,10 +147,13 @@ func NoContent(ctx context.Context) error {
 
 // Bytes writes the bytes to the HTTP response with the given code and content type
 func Bytes(ctx context.Context, code int, contentType string, b []byte) error {
-	w := ResponseWriter(ctx)
+	w, err := writer(ctx)
+	if err != nil {
+		return err
+	}
 	w.WriteHeader(code)
 	w.Header().Set("Content-Type", contentType)
-	_, err := w.Write(b)
+	_, err = w.Write(b)
 	return err
 }
 